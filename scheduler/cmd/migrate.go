@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"scheduler/config"
+	"scheduler/internal/db/sqlite"
+	"strconv"
+	"text/tabwriter"
+)
+
+const migrateUsage = `usage: main migrate <command>
+  up            applies the pending migrations
+  down [steps]  rolls back the most recent migrations, 1 by default
+  status        lists the migrations and when they were applied`
+
+// Migrate Runs the migration command of the command line against the state store and exits
+func Migrate(args []string) {
+	err := sqlite.InitSqliteDatabase(config.GetConfig().Sqlite)
+	if err != nil {
+		exitWithError(fmt.Errorf("failed to initialize the database: %w", err))
+	}
+	if len(args) == 0 {
+		exitWithError(fmt.Errorf("missing command\n%s", migrateUsage))
+	}
+
+	switch args[0] {
+	case "up":
+		err = sqlite.MigrateDatabase()
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps <= 0 {
+				exitWithError(fmt.Errorf("invalid number of steps: %s", args[1]))
+			}
+		}
+		err = sqlite.RollbackDatabase(steps)
+	case "status":
+		err = printMigrationStatus()
+	default:
+		err = fmt.Errorf("unknown command: %s\n%s", args[0], migrateUsage)
+	}
+	if err != nil {
+		exitWithError(err)
+	}
+}
+
+func printMigrationStatus() error {
+	statuses, err := sqlite.MigrationStatuses()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := status.AppliedAt
+		if appliedAt == "" {
+			appliedAt = "pending"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+	}
+	return w.Flush()
+}
+
+func exitWithError(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -6,7 +6,10 @@ import (
 	"log"
 	"scheduler/config"
 	"scheduler/internal/app/scheduler"
+	"scheduler/internal/clock"
+	"scheduler/internal/datasource"
 	"scheduler/internal/db/sqlite"
+	"scheduler/internal/helper"
 	"scheduler/internal/local_cron"
 	"scheduler/internal/logger"
 	"scheduler/internal/repository"
@@ -27,22 +30,63 @@ func SetUpLogger() {
 	logger.InitLogger("zap")
 }
 
-// SetUpDatabase Intiailizing the database. Currently it is using sqlite
+// SetUpDatabase Intiailizing the state store of the scheduler and applying its pending migrations. Currently it is
+// using sqlite
 func SetUpDatabase() {
 	logger.Log.Info("Initializing database connection")
 	err := sqlite.InitSqliteDatabase(config.GetConfig().Sqlite)
 	if err != nil {
 		logger.Log.Fatal("Failed to initialize the database", zap.Error(err))
 	}
+	err = sqlite.MigrateDatabase()
+	if err != nil {
+		logger.Log.Fatal("Failed to migrate the database", zap.Error(err))
+	}
+	err = sqlite.ImportState(config.GetConfig().Sqlite.ImportFrom)
+	if err != nil {
+		logger.Log.Fatal("Failed to import the state of the scheduler", zap.Error(err))
+	}
 	logger.Log.Info("Database initialized")
 }
 
+// SetUpDataSources Opening the data sources the queries of the jobs run on
+func SetUpDataSources() {
+	logger.Log.Info("Initializing data sources")
+	err := datasource.InitDataSources(config.GetConfig().DataSources)
+	if err != nil {
+		logger.Log.Fatal("Failed to initialize the data sources", zap.Error(err))
+	}
+	logger.Log.Info("Data sources initialized")
+}
+
 // SetUpCron Setting up the cron to run the scheduled jobs
 func SetUpCron() {
 	logger.Log.Info("Initializing Cron")
-	local_cron.InitCron()
+	timezone := config.GetConfig().Scheduler.Timezone
+	loc, err := helper.LoadLocation(timezone)
+	if err != nil {
+		logger.Log.Fatal("Invalid scheduler timezone", zap.String("timezone", timezone), zap.Error(err))
+	}
+	local_cron.InitCron(loc)
 	local_cron.StartCron()
-	logger.Log.Info("Cron Initialized")
+	logger.Log.Info("Cron Initialized", zap.String("timezone", loc.String()))
+}
+
+// SetUpClock Setting up the clock the time windows of the runs are computed from. Without a configured reference now
+// the windows are relative to the fire time of each run
+func SetUpClock() {
+	referenceNow := config.GetConfig().Scheduler.ReferenceNow
+	if referenceNow == "" {
+		clock.InitClock(clock.RealClock{})
+		logger.Log.Info("Clock initialized", zap.String("mode", "real"))
+		return
+	}
+	now, err := time.ParseInLocation(helper.TimeLayout, referenceNow, helper.SchedulerLocation())
+	if err != nil {
+		logger.Log.Fatal("Invalid reference now", zap.String("reference_now", referenceNow), zap.Error(err))
+	}
+	clock.InitClock(clock.FixedClock{Now: now})
+	logger.Log.Info("Clock initialized", zap.String("mode", "fixed"), zap.Time("reference_now", now))
 }
 
 // LoadSchedules Loading the existing schedules
@@ -58,7 +102,43 @@ func LoadSchedules() {
 	}
 }
 
+// defaultShutdownGracePeriod Grace period for the in-flight runs when none is configured
+const defaultShutdownGracePeriod = 30 * time.Second
+
+// cronStopTimeout How long the shutdown waits for the fires of the cron once the in-flight runs are interrupted
+const cronStopTimeout = 5 * time.Second
+
+// ShutDown Stopping the cron from firing and draining the in-flight runs. Runs which do not finish within the grace
+// period are interrupted and resumed on the next boot
 func ShutDown() {
 	logger.Log.Info("Shutting down cron")
-	local_cron.StopCron()
+	stopped := local_cron.StopCron()
+
+	gracePeriod := config.GetConfig().Scheduler.ShutdownGracePeriod
+	if gracePeriod <= 0 {
+		gracePeriod = defaultShutdownGracePeriod
+	}
+	logger.Log.Info("Waiting for in-flight runs", zap.Duration("grace_period", gracePeriod))
+	ctx, cancel := context.WithTimeout(context.Background(), gracePeriod)
+	defer cancel()
+	// fires which wait for a running copy of their job are only done once they ran, so they are waited for before the
+	// runs are drained
+	select {
+	case <-stopped.Done():
+	case <-ctx.Done():
+	}
+	interrupted := scheduler.DrainRuns(ctx)
+	if interrupted > 0 {
+		logger.Log.Warn("Interrupted in-flight runs", zap.Int("runs", interrupted))
+	}
+	// the fires which were still waiting start once the runs they waited for are interrupted, and are interrupted
+	// right away too
+	waitCtx, cancelWait := context.WithTimeout(context.Background(), cronStopTimeout)
+	defer cancelWait()
+	select {
+	case <-stopped.Done():
+	case <-waitCtx.Done():
+		logger.Log.Warn("Fires of the cron are still waiting for their job")
+	}
+	datasource.CloseDataSources()
 }
@@ -5,6 +5,7 @@ import (
 	"github.com/spf13/viper"
 	"log"
 	"sync"
+	"time"
 )
 
 var config *Config
@@ -16,8 +17,12 @@ type Config struct {
 	HttpServer HttpServer `yaml:"httpServer"`
 	Log        Log        `yaml:"log"`
 	Scheduler  Scheduler  `yaml:"scheduler"`
+	// Sqlite is the state store of the scheduler, which keeps the jobs, their runs and their backfills
 	Sqlite     Sqlite     `yaml:"sqlite"`
 	PostResult PostResult `yaml:"postResult"`
+	// DataSources are the databases the queries of the jobs run on, read-only. The jobs which do not pick a source
+	// query the one named default
+	DataSources []DataSource `yaml:"dataSources"`
 }
 
 type HttpServer struct {
@@ -37,10 +42,34 @@ type Sqlite struct {
 	Path            string `yaml:"path"`
 	MaxConnections  int    `yaml:"maxConnections"`
 	MaxConnIdleTime int    `yaml:"maxConnIdleTime"`
+	// ImportFrom is the database the state of the scheduler was kept in before. Its jobs, runs and backfills are
+	// copied into the store while the store has no jobs
+	ImportFrom string `yaml:"importFrom"`
+}
+
+type DataSource struct {
+	Name string `yaml:"name"`
+	// Driver is sqlite or csv
+	Driver string `yaml:"driver"`
+	// Path is the database file of a sqlite source and the directory of the csv files of a csv source
+	Path               string `yaml:"path"`
+	MaxConnections     int    `yaml:"maxConnections"`
+	MaxIdleConnections int    `yaml:"maxIdleConnections"`
+	// Allow and Deny list the tables and the columns ("table.column") jobs can use. Without an allow list every
+	// table can be used. Deny always wins and the tables of the scheduler can never be used
+	Allow []string `yaml:"allow"`
+	Deny  []string `yaml:"deny"`
 }
 
 type Scheduler struct {
 	Timezone string `yaml:"timezone"`
+	// ShutdownGracePeriod is how long the shutdown waits for in-flight runs before interrupting them
+	ShutdownGracePeriod time.Duration `yaml:"shutdownGracePeriod"`
+	// ReferenceNow fixes the "now" the time windows of the runs are computed from, in the timezone of the scheduler.
+	// When it is empty the windows are relative to the fire time of each run
+	ReferenceNow string `yaml:"referenceNow"`
+	// MinInterval is how soon a job can fire again after a fire. Jobs whose cron fires more often are rejected
+	MinInterval time.Duration `yaml:"minInterval"`
 }
 
 type PostResult struct {
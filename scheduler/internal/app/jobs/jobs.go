@@ -3,6 +3,8 @@ package jobs
 import (
 	"context"
 	"scheduler/internal/app/scheduler"
+	"scheduler/internal/app/scheduler_strategy"
+	"scheduler/internal/datasource"
 	"scheduler/internal/db/model"
 	"scheduler/internal/helper"
 	"scheduler/internal/interface/request"
@@ -16,6 +18,16 @@ type JobsApp interface {
 	AddJob(context.Context, request.SchedulerRequest) error
 	GetAllJobs(context.Context) ([]response.Jobs, error)
 	DeleteAJob(context.Context, int) error
+	GetJobRuns(context.Context, int, request.JobRunsRequest) (response.JobRuns, error)
+	RunJobNow(context.Context, int, bool) (response.TriggeredRun, error)
+	GetJobRequest(context.Context, int) (request.SchedulerRequest, error)
+	UpdateJob(context.Context, int, request.SchedulerRequest) error
+	PauseJob(context.Context, int) error
+	ResumeJob(context.Context, int) error
+	CancelRun(context.Context, int, string) error
+	BackfillJob(context.Context, int, request.BackfillRequest) (response.Backfill, error)
+	GetBackfill(context.Context, int, string) (response.Backfill, error)
+	CancelBackfill(context.Context, int, string) error
 }
 
 type jobsAppImpl struct {
@@ -34,36 +46,81 @@ func (j *jobsAppImpl) GetAllJobs(ctx context.Context) ([]response.Jobs, error) {
 	if err != nil {
 		return nil, err
 	}
+	watermarks, err := j.Repo.Watermark.GetAllWatermarks(ctx)
+	if err != nil {
+		return nil, err
+	}
 	var resp []response.Jobs
 	for _, job := range jobs {
+		loc := helper.JobLocation(job.Timezone)
 		resp = append(resp, response.Jobs{
-			ID:             job.ID,
-			Name:           job.Name,
-			CronExpression: job.CronExpression,
-			Enabled:        job.Enabled,
-			Table:          job.Table,
-			Field:          job.Field,
-			Aggregation:    job.Aggregation,
-			Duration:       job.Duration,
-			DurationFilter: job.DurationFilter,
-			CreatedAt:      job.CreatedAt,
-			LastRun:        job.LastRun,
-			NextRun:        job.NextRun,
+			ID:                job.ID,
+			Name:              job.Name,
+			CronExpression:    job.CronExpression,
+			Enabled:           job.Enabled,
+			Status:            job.Status,
+			Table:             job.Table,
+			Field:             job.Field,
+			Aggregation:       job.Aggregation,
+			Duration:          job.Duration,
+			DurationFilter:    job.DurationFilter,
+			Timezone:          loc.String(),
+			CreatedAt:         helper.FormatStoredTime(job.CreatedAt, loc),
+			LastRun:           helper.FormatStoredTime(job.LastRun, loc),
+			NextRun:           helper.FormatStoredTime(job.NextRun, loc),
+			ConcurrencyPolicy: job.ConcurrencyPolicy,
+			MisfirePolicy:     job.MisfirePolicy,
+			MaxRuntime:        job.MaxRuntime,
+			RetryPolicy: response.RetryPolicy{
+				MaxAttempts:       job.RetryMaxAttempts,
+				InitialBackoff:    job.RetryInitialBackoff,
+				Multiplier:        job.RetryMultiplier,
+				MaxBackoff:        job.RetryMaxBackoff,
+				RetryableStatuses: job.RetryableStatuses,
+			},
+			Type:            job.Type,
+			Query:           job.Query,
+			Parameters:      job.Parameters,
+			Measures:        measureRequests(job.Measures),
+			Filters:         filterRequests(job.Filters),
+			DataSource:      dataSourceName(job.DataSource),
+			WatermarkColumn: job.WatermarkColumn,
+			Watermark:       watermarks[job.ID].Value,
 		})
 	}
 	return resp, nil
 }
 
-// DeleteAJob delete a job from a database. It basically set the enabled as false
+// DeleteAJob delete a job from a database. It basically marks the job as deleted so that its history is kept
 func (j *jobsAppImpl) DeleteAJob(ctx context.Context, jobID int) error {
 	job, err := j.Repo.Job.GetAJobFromID(ctx, jobID)
+	if err != nil || job.Status == model.JobStatusDeleted {
+		return exception.DataNotFoundError
+	}
+	err = j.Repo.Job.UpdateJob(ctx, job, statusUpdates(model.JobStatusDeleted))
 	if err != nil {
+		return exception.UpdateFailedError
+	}
+	if job.Status != model.JobStatusActive {
+		return nil
+	}
+	err = j.sch.RemoveJob(job.ID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// PauseJob Stop scheduling an active job until it is resumed
+func (j *jobsAppImpl) PauseJob(ctx context.Context, jobID int) error {
+	job, err := j.Repo.Job.GetAJobFromID(ctx, jobID)
+	if err != nil || job.Status == model.JobStatusDeleted {
 		return exception.DataNotFoundError
 	}
-	updates := map[string]interface{}{
-		"enabled": false,
+	if job.Status != model.JobStatusActive {
+		return exception.JobNotActiveError
 	}
-	err = j.Repo.Job.UpdateJob(ctx, job, updates)
+	err = j.Repo.Job.UpdateJob(ctx, job, statusUpdates(model.JobStatusPaused))
 	if err != nil {
 		return exception.UpdateFailedError
 	}
@@ -74,25 +131,54 @@ func (j *jobsAppImpl) DeleteAJob(ctx context.Context, jobID int) error {
 	return nil
 }
 
+// ResumeJob Schedule a paused job again. Fire times which passed while it was paused are not recovered
+func (j *jobsAppImpl) ResumeJob(ctx context.Context, jobID int) error {
+	job, err := j.Repo.Job.GetAJobFromID(ctx, jobID)
+	if err != nil || job.Status == model.JobStatusDeleted {
+		return exception.DataNotFoundError
+	}
+	if job.Status != model.JobStatusPaused {
+		return exception.JobNotPausedError
+	}
+	err = j.Repo.Job.UpdateJob(ctx, job, statusUpdates(model.JobStatusActive))
+	if err != nil {
+		return exception.UpdateFailedError
+	}
+	job.Status = model.JobStatusActive
+	job.Enabled = true
+	err = j.sch.AddJob(ctx, job)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// statusUpdates The columns which are written when the status of a job changes
+func statusUpdates(status string) map[string]interface{} {
+	return map[string]interface{}{
+		"status":  status,
+		"enabled": status == model.JobStatusActive,
+	}
+}
+
 // AddJob Add a job in the database and to the scheduler for execution
 func (j *jobsAppImpl) AddJob(ctx context.Context, requestBody request.SchedulerRequest) error {
-	nextRun, err := helper.GetNextRun(requestBody.CronSchedule)
+	nextRun, err := helper.GetNextRun(requestBody.CronSchedule, helper.JobLocation(requestBody.Timezone))
+	if err != nil {
+		return err
+	}
+	err = j.checkReadOnly(ctx, requestBody)
 	if err != nil {
 		return err
 	}
 	newJob := model.CronJob{
-		Name:           requestBody.Name,
-		CronExpression: requestBody.CronSchedule,
-		Enabled:        true,
-		Table:          requestBody.Table,
-		Field:          requestBody.Field,
-		Aggregation:    requestBody.Aggregation,
-		Duration:       requestBody.DurationOption,
-		DurationFilter: requestBody.DurationFilter,
-		CreatedAt:      time.Now().UTC().Format("2006-01-02 15:04:05"),
-		LastRun:        "",
-		NextRun:        nextRun.Format("2006-01-02 15:04:05"),
+		Enabled:   true,
+		Status:    model.JobStatusActive,
+		CreatedAt: time.Now().UTC().Format(helper.TimeLayout),
+		LastRun:   "",
+		NextRun:   nextRun.UTC().Format(helper.TimeLayout),
 	}
+	applyRequest(&newJob, requestBody)
 
 	err = j.Repo.Job.AddAJob(ctx, &newJob)
 	if err != nil {
@@ -104,3 +190,356 @@ func (j *jobsAppImpl) AddJob(ctx context.Context, requestBody request.SchedulerR
 	}
 	return nil
 }
+
+// GetJobRequest returns the definition of a job in the shape of the request which creates it
+func (j *jobsAppImpl) GetJobRequest(ctx context.Context, jobID int) (request.SchedulerRequest, error) {
+	job, err := j.Repo.Job.GetAJobFromID(ctx, jobID)
+	if err != nil || job.Status == model.JobStatusDeleted {
+		return request.SchedulerRequest{}, exception.DataNotFoundError
+	}
+	return request.SchedulerRequest{
+		Name:              job.Name,
+		Table:             job.Table,
+		Field:             job.Field,
+		Aggregation:       job.Aggregation,
+		DurationFilter:    job.DurationFilter,
+		DurationOption:    job.Duration,
+		CronSchedule:      job.CronExpression,
+		Timezone:          job.Timezone,
+		ConcurrencyPolicy: job.ConcurrencyPolicy,
+		MisfirePolicy:     job.MisfirePolicy,
+		MaxRuntime:        job.MaxRuntime,
+		Type:              job.Type,
+		Query:             job.Query,
+		Parameters:        job.Parameters,
+		Measures:          measureRequests(job.Measures),
+		Filters:           filterRequests(job.Filters),
+		DataSource:        job.DataSource,
+		WatermarkColumn:   job.WatermarkColumn,
+		RetryPolicy: &request.RetryPolicyRequest{
+			MaxAttempts:       job.RetryMaxAttempts,
+			InitialBackoff:    job.RetryInitialBackoff,
+			Multiplier:        job.RetryMultiplier,
+			MaxBackoff:        job.RetryMaxBackoff,
+			RetryableStatuses: job.RetryableStatuses,
+		},
+	}, nil
+}
+
+// UpdateJob Replace the definition of a job in the database and swap it in the scheduler. Paused jobs stay paused.
+// When the job can not be rescheduled the stored definition is restored. Once the new definition is in place the
+// watermark of the job is dropped, so the next run of an incremental job recomputes everything with it. The previous
+// definition is restored when the watermark can not be dropped
+func (j *jobsAppImpl) UpdateJob(ctx context.Context, jobID int, requestBody request.SchedulerRequest) error {
+	job, err := j.Repo.Job.GetAJobFromID(ctx, jobID)
+	if err != nil || job.Status == model.JobStatusDeleted {
+		return exception.DataNotFoundError
+	}
+	err = j.checkReadOnly(ctx, requestBody)
+	if err != nil {
+		return err
+	}
+	oldJob := job
+	applyRequest(&job, requestBody)
+	nextRun, err := helper.GetNextRun(job.CronExpression, helper.JobLocation(job.Timezone))
+	if err != nil {
+		return err
+	}
+	job.NextRun = nextRun.UTC().Format(helper.TimeLayout)
+
+	err = j.Repo.Job.UpdateJob(ctx, job, definitionUpdates(job))
+	if err != nil {
+		return exception.UpdateFailedError
+	}
+	if job.Status == model.JobStatusActive {
+		err = j.sch.AddJob(ctx, job)
+		if err != nil {
+			if rollbackErr := j.Repo.Job.UpdateJob(ctx, oldJob, definitionUpdates(oldJob)); rollbackErr != nil {
+				return exception.UpdateFailedError
+			}
+			return err
+		}
+	}
+	err = j.Repo.Watermark.DeleteWatermark(ctx, job.ID)
+	if err != nil {
+		if rollbackErr := j.Repo.Job.UpdateJob(ctx, oldJob, definitionUpdates(oldJob)); rollbackErr == nil && job.Status == model.JobStatusActive {
+			_ = j.sch.AddJob(ctx, oldJob)
+		}
+		return exception.UpdateFailedError
+	}
+	return nil
+}
+
+// measureRequests Returns the measures of a job in the shape of the request
+func measureRequests(measures model.MeasureList) []request.MeasureRequest {
+	var requests []request.MeasureRequest
+	for _, measure := range measures {
+		requests = append(requests, request.MeasureRequest{
+			Field:       measure.Field,
+			Aggregation: measure.Aggregation,
+			Alias:       measure.Alias,
+		})
+	}
+	return requests
+}
+
+// filterRequests Returns the filters of a job in the shape of the request
+func filterRequests(filters model.FilterList) []request.FilterRequest {
+	var requests []request.FilterRequest
+	for _, filter := range filters {
+		requests = append(requests, request.FilterRequest{
+			Field:    filter.Field,
+			Operator: filter.Operator,
+			Value:    filter.Value,
+		})
+	}
+	return requests
+}
+
+// dataSourceName Returns the name of the data source of a job, the default source when it has none
+func dataSourceName(source string) string {
+	if source == "" {
+		return datasource.DefaultName
+	}
+	return source
+}
+
+// checkReadOnly Rejects the query of a sql job when it can not be prepared or SQLite does not report it as read-only
+func (j *jobsAppImpl) checkReadOnly(ctx context.Context, requestBody request.SchedulerRequest) error {
+	if requestBody.Type != model.JobTypeSql {
+		return nil
+	}
+	readOnly, err := j.Repo.DataSource.IsReadOnlyQuery(ctx, requestBody.DataSource, requestBody.Query)
+	if err != nil {
+		return exception.InvalidQueryError
+	}
+	if !readOnly {
+		return exception.QueryNotReadOnlyError
+	}
+	return nil
+}
+
+// applyRequest Copy the definition of a job from the request
+func applyRequest(job *model.CronJob, requestBody request.SchedulerRequest) {
+	job.Name = requestBody.Name
+	job.CronExpression = requestBody.CronSchedule
+	job.Table = requestBody.Table
+	job.Field = requestBody.Field
+	job.Aggregation = requestBody.Aggregation
+	job.Duration = requestBody.DurationOption
+	job.DurationFilter = requestBody.DurationFilter
+	job.Timezone = requestBody.Timezone
+	job.ConcurrencyPolicy = requestBody.ConcurrencyPolicy
+	if job.ConcurrencyPolicy == "" {
+		job.ConcurrencyPolicy = model.ConcurrencyPolicyAllow
+	}
+	job.MisfirePolicy = requestBody.MisfirePolicy
+	if job.MisfirePolicy == "" {
+		job.MisfirePolicy = model.MisfirePolicyIgnore
+	}
+	job.MaxRuntime = requestBody.MaxRuntime
+	retryPolicy := request.RetryPolicyRequest{}
+	if requestBody.RetryPolicy != nil {
+		retryPolicy = *requestBody.RetryPolicy
+	}
+	job.RetryMaxAttempts = retryPolicy.MaxAttempts
+	job.RetryInitialBackoff = retryPolicy.InitialBackoff
+	job.RetryMultiplier = retryPolicy.Multiplier
+	job.RetryMaxBackoff = retryPolicy.MaxBackoff
+	job.RetryableStatuses = retryPolicy.RetryableStatuses
+	job.Measures = nil
+	for _, measure := range requestBody.Measures {
+		job.Measures = append(job.Measures, model.Measure{
+			Field:       measure.Field,
+			Aggregation: measure.Aggregation,
+			Alias:       measure.Alias,
+		})
+	}
+	job.Filters = nil
+	for _, filter := range requestBody.Filters {
+		job.Filters = append(job.Filters, model.Filter{
+			Field:    filter.Field,
+			Operator: filter.Operator,
+			Value:    filter.Value,
+		})
+	}
+	if len(job.Measures) > 0 {
+		job.Field = job.Measures[0].Field
+		job.Aggregation = job.Measures[0].Aggregation
+	}
+	job.Type = requestBody.Type
+	job.Query = requestBody.Query
+	job.DataSource = requestBody.DataSource
+	job.WatermarkColumn = requestBody.WatermarkColumn
+	job.Parameters = nil
+	if definition, ok := scheduler_strategy.Lookup(job.Duration); ok && job.Type != model.JobTypeSql {
+		job.Parameters = definition.ParameterValues(requestBody.Parameters)
+	}
+	if job.Type == model.JobTypeSql {
+		job.Table, job.Field, job.Aggregation, job.DurationFilter = "", "", "", ""
+		job.Measures = nil
+		job.Filters = nil
+		job.WatermarkColumn = ""
+	} else {
+		job.Type = model.JobTypeAggregation
+		job.Query = ""
+	}
+}
+
+// definitionUpdates The columns which are written when the definition of a job changes
+func definitionUpdates(job model.CronJob) map[string]interface{} {
+	return map[string]interface{}{
+		"name":                  job.Name,
+		"cron_expression":       job.CronExpression,
+		"table":                 job.Table,
+		"field":                 job.Field,
+		"aggregation":           job.Aggregation,
+		"duration":              job.Duration,
+		"duration_filter":       job.DurationFilter,
+		"timezone":              job.Timezone,
+		"concurrency_policy":    job.ConcurrencyPolicy,
+		"misfire_policy":        job.MisfirePolicy,
+		"max_runtime":           job.MaxRuntime,
+		"retry_max_attempts":    job.RetryMaxAttempts,
+		"retry_initial_backoff": job.RetryInitialBackoff,
+		"retry_multiplier":      job.RetryMultiplier,
+		"retry_max_backoff":     job.RetryMaxBackoff,
+		"retryable_statuses":    job.RetryableStatuses,
+		"next_run":              job.NextRun,
+		"type":                  job.Type,
+		"query":                 job.Query,
+		"parameters":            job.Parameters,
+		"measures":              job.Measures,
+		"filters":               job.Filters,
+		"data_source":           job.DataSource,
+		"watermark_column":      job.WatermarkColumn,
+	}
+}
+
+// GetJobRuns returns a page of the run history of a job
+func (j *jobsAppImpl) GetJobRuns(ctx context.Context, jobID int, runsRequest request.JobRunsRequest) (response.JobRuns, error) {
+	job, err := j.Repo.Job.GetAJobFromID(ctx, jobID)
+	if err != nil {
+		return response.JobRuns{}, exception.DataNotFoundError
+	}
+	filter := repository.JobRunFilter{
+		Statuses: runsRequest.Statuses(),
+		Limit:    runsRequest.PageSize,
+		Offset:   (runsRequest.Page - 1) * runsRequest.PageSize,
+	}
+	runs, total, err := j.Repo.JobRun.GetRunsForJob(ctx, job.ID, filter)
+	if err != nil {
+		return response.JobRuns{}, err
+	}
+	loc := helper.JobLocation(job.Timezone)
+	resp := response.JobRuns{
+		Runs:     []response.JobRun{},
+		Page:     runsRequest.Page,
+		PageSize: runsRequest.PageSize,
+		Total:    total,
+	}
+	for _, run := range runs {
+		resp.Runs = append(resp.Runs, response.JobRun{
+			ID:          run.ID,
+			JobID:       run.JobID,
+			Trigger:     run.Trigger,
+			ScheduledAt: helper.FormatStoredTime(run.ScheduledAt, loc),
+			StartedAt:   helper.FormatStoredTime(run.StartedAt, loc),
+			FinishedAt:  helper.FormatStoredTime(run.FinishedAt, loc),
+			Status:      run.Status,
+			RowCount:    run.RowCount,
+			Error:       run.Error,
+			HttpStatus:  run.HttpStatus,
+			Attempts:    run.Attempts,
+			BackfillID:  run.BackfillID,
+		})
+	}
+	return resp, nil
+}
+
+// RunJobNow runs a job immediately outside of its schedule. When wait is set the outcome of the run is returned
+func (j *jobsAppImpl) RunJobNow(ctx context.Context, jobID int, wait bool) (response.TriggeredRun, error) {
+	job, err := j.Repo.Job.GetAJobFromID(ctx, jobID)
+	if err != nil || job.Status == model.JobStatusDeleted {
+		return response.TriggeredRun{}, exception.DataNotFoundError
+	}
+	result := j.sch.TriggerJob(ctx, job, wait)
+	return response.TriggeredRun{
+		RunID:        result.Run.ID,
+		Status:       result.Run.Status,
+		Query:        result.Query,
+		RowCount:     result.Run.RowCount,
+		Error:        result.Run.Error,
+		HttpStatus:   result.Run.HttpStatus,
+		SinkResponse: string(result.SinkResponse),
+	}, nil
+}
+
+// CancelRun aborts an in-flight run of a job
+func (j *jobsAppImpl) CancelRun(ctx context.Context, jobID int, runID string) error {
+	run, err := j.Repo.JobRun.GetRun(ctx, runID)
+	if err != nil || run.JobID != uint(jobID) {
+		return exception.DataNotFoundError
+	}
+	return j.sch.CancelRun(run.JobID, run.ID)
+}
+
+// BackfillJob starts running a job once per period of the given date range. The dates are in the timezone of the job
+func (j *jobsAppImpl) BackfillJob(ctx context.Context, jobID int, backfillRequest request.BackfillRequest) (response.Backfill, error) {
+	job, err := j.Repo.Job.GetAJobFromID(ctx, jobID)
+	if err != nil || job.Status == model.JobStatusDeleted {
+		return response.Backfill{}, exception.DataNotFoundError
+	}
+	loc := helper.JobLocation(job.Timezone)
+	start, err := time.ParseInLocation(helper.DateLayout, backfillRequest.StartDate, loc)
+	if err != nil {
+		return response.Backfill{}, exception.NewValidationError("start_date", "has to be a date formatted as "+helper.DateLayout)
+	}
+	end, err := time.ParseInLocation(helper.DateLayout, backfillRequest.EndDate, loc)
+	if err != nil {
+		return response.Backfill{}, exception.NewValidationError("end_date", "has to be a date formatted as "+helper.DateLayout)
+	}
+	backfill, err := j.sch.Backfill(ctx, job, start, end)
+	if err != nil {
+		return response.Backfill{}, err
+	}
+	return backfillResponse(backfill, loc), nil
+}
+
+// GetBackfill returns the progress of a backfill of a job
+func (j *jobsAppImpl) GetBackfill(ctx context.Context, jobID int, backfillID string) (response.Backfill, error) {
+	job, err := j.Repo.Job.GetAJobFromID(ctx, jobID)
+	if err != nil {
+		return response.Backfill{}, exception.DataNotFoundError
+	}
+	backfill, err := j.Repo.Backfill.GetBackfill(ctx, backfillID)
+	if err != nil || backfill.JobID != job.ID {
+		return response.Backfill{}, exception.DataNotFoundError
+	}
+	return backfillResponse(backfill, helper.JobLocation(job.Timezone)), nil
+}
+
+// CancelBackfill aborts an in-flight backfill of a job
+func (j *jobsAppImpl) CancelBackfill(ctx context.Context, jobID int, backfillID string) error {
+	backfill, err := j.Repo.Backfill.GetBackfill(ctx, backfillID)
+	if err != nil || backfill.JobID != uint(jobID) {
+		return exception.DataNotFoundError
+	}
+	return j.sch.CancelBackfill(backfill.JobID, backfill.ID)
+}
+
+func backfillResponse(backfill model.Backfill, loc *time.Location) response.Backfill {
+	return response.Backfill{
+		ID:               backfill.ID,
+		JobID:            backfill.JobID,
+		StartDate:        backfill.StartDate,
+		EndDate:          backfill.EndDate,
+		Status:           backfill.Status,
+		TotalPeriods:     backfill.TotalPeriods,
+		CompletedPeriods: backfill.CompletedPeriods,
+		FailedPeriods:    backfill.FailedPeriods,
+		CreatedAt:        helper.FormatStoredTime(backfill.CreatedAt, loc),
+		FinishedAt:       helper.FormatStoredTime(backfill.FinishedAt, loc),
+		Error:            backfill.Error,
+	}
+}
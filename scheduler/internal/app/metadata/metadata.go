@@ -0,0 +1,71 @@
+package metadata
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"scheduler/internal/app/scheduler_strategy"
+	"scheduler/internal/datasource"
+	"scheduler/internal/interface/response"
+	"scheduler/internal/logger"
+	"time"
+)
+
+// healthCheckTimeout bounds the health check of a single data source
+const healthCheckTimeout = 2 * time.Second
+
+type MetadataApp interface {
+	GetMetadata(context.Context) response.MetadataResponse
+	GetDataSources(context.Context) []response.DataSource
+}
+
+type metadataAppImpl struct{}
+
+func NewMetadataApp() MetadataApp {
+	return &metadataAppImpl{}
+}
+
+// GetMetadata Lists the tables the jobs can aggregate per data source, as discovered in their schema and limited by
+// their allow and deny lists, together with the strategies of the durations. Sources whose schema can not be read are
+// left out
+func (a *metadataAppImpl) GetMetadata(ctx context.Context) response.MetadataResponse {
+	var metrics []response.MetricConfig
+	for _, source := range datasource.GetDataSources() {
+		tables, err := source.Tables(ctx)
+		if err != nil {
+			logger.Log.Warn("Unable to read the schema of the data source", zap.String("data_source", source.Name), zap.Error(err))
+			continue
+		}
+		for _, table := range tables {
+			metrics = append(metrics, response.NewMetricConfig(source.Name, table))
+		}
+	}
+	return response.MetadataResponse{
+		Metrics:    metrics,
+		Durations:  scheduler_strategy.DurationNames(),
+		Strategies: scheduler_strategy.Definitions(),
+	}
+}
+
+// GetDataSources Checks the health of every data source and returns it with the state of its connection pool
+func (a *metadataAppImpl) GetDataSources(ctx context.Context) []response.DataSource {
+	var sources []response.DataSource
+	for _, source := range datasource.GetDataSources() {
+		resp := response.DataSource{Name: source.Name, Driver: source.Driver, Healthy: true}
+		checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		err := source.HealthCheck(checkCtx)
+		cancel()
+		if err != nil {
+			resp.Healthy = false
+			resp.Error = err.Error()
+		}
+		if sqlDB, err := source.DB.DB(); err == nil {
+			stats := sqlDB.Stats()
+			resp.MaxOpenConnections = stats.MaxOpenConnections
+			resp.OpenConnections = stats.OpenConnections
+			resp.InUse = stats.InUse
+			resp.Idle = stats.Idle
+		}
+		sources = append(sources, resp)
+	}
+	return sources
+}
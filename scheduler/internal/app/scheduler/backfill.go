@@ -0,0 +1,175 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"scheduler/internal/app/scheduler_strategy"
+	"scheduler/internal/db/model"
+	"scheduler/internal/helper"
+	"scheduler/internal/logger"
+	"scheduler/pkg/exception"
+	"sync"
+	"time"
+)
+
+// maxBackfillPeriods Upper bound of the periods of a single backfill
+const maxBackfillPeriods = 1000
+
+var errBackfillCancelled = errors.New("backfill was cancelled")
+
+// backfillRegistry Keeps the cancel functions of the in-flight backfills
+type backfillRegistry struct {
+	mu        sync.Mutex
+	backfills map[string]inFlightRun
+}
+
+var activeBackfills = &backfillRegistry{backfills: make(map[string]inFlightRun)}
+
+// start Creates the context of a backfill and registers it so it can be cancelled
+func (r *backfillRegistry) start(jobID uint, backfillID string) context.Context {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	r.mu.Lock()
+	r.backfills[backfillID] = inFlightRun{jobID: jobID, cancel: cancel}
+	r.mu.Unlock()
+	return ctx
+}
+
+// release Unregisters a finished backfill
+func (r *backfillRegistry) release(backfillID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if backfill, ok := r.backfills[backfillID]; ok {
+		backfill.cancel(nil)
+		delete(r.backfills, backfillID)
+	}
+}
+
+// cancel Aborts an in-flight backfill of the job. It returns false when the backfill is not in progress
+func (r *backfillRegistry) cancel(jobID uint, backfillID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	backfill, ok := r.backfills[backfillID]
+	if !ok || backfill.jobID != jobID {
+		return false
+	}
+	backfill.cancel(errBackfillCancelled)
+	return true
+}
+
+// Backfill Runs the job once per period of the strategy of the job between the start and end date, both included.
+// The periods run one after another in the background and the returned backfill tracks their progress
+func (a *appScheduler) Backfill(ctx context.Context, job model.CronJob, start time.Time, end time.Time) (model.Backfill, error) {
+	strategyImpl, err := scheduler_strategy.GetStrategy(job)
+	if err != nil {
+		return model.Backfill{}, err
+	}
+	periodStrategy, ok := strategyImpl.(scheduler_strategy.PeriodStrategy)
+	if !ok {
+		return model.Backfill{}, exception.BackfillNotSupportedError
+	}
+	periods := periodStrategy.Periods(job, start, end)
+	if len(periods) > maxBackfillPeriods {
+		return model.Backfill{}, exception.BackfillTooLargeError
+	}
+
+	backfill := model.Backfill{
+		ID:           uuid.NewString(),
+		JobID:        job.ID,
+		StartDate:    start.Format(helper.DateLayout),
+		EndDate:      end.Format(helper.DateLayout),
+		Status:       model.BackfillStatusRunning,
+		TotalPeriods: len(periods),
+		CreatedAt:    time.Now().UTC().Format(helper.TimeLayout),
+	}
+	err = a.repo.Backfill.AddBackfill(ctx, &backfill)
+	if err != nil {
+		return model.Backfill{}, err
+	}
+	backfillCtx := activeBackfills.start(job.ID, backfill.ID)
+	go a.runBackfill(backfillCtx, job, backfill, periodStrategy, periods)
+	return backfill, nil
+}
+
+// CancelBackfill Aborts an in-flight backfill of the job together with the run of its current period
+func (a *appScheduler) CancelBackfill(jobID uint, backfillID string) error {
+	if !activeBackfills.cancel(jobID, backfillID) {
+		return exception.BackfillNotInProgressError
+	}
+	return nil
+}
+
+// runBackfill Runs the periods one after another, recording the progress after every period. A failed period does
+// not stop the backfill, a cancellation or a shutdown does
+func (a *appScheduler) runBackfill(ctx context.Context, job model.CronJob, backfill model.Backfill,
+	strategy scheduler_strategy.PeriodStrategy, periods []scheduler_strategy.Period) {
+	defer activeBackfills.release(backfill.ID)
+	logger.Log.Info("Backfill started", zap.String("job_name", job.Name), zap.String("backfill_id", backfill.ID),
+		zap.Int("periods", len(periods)))
+
+	// a period waits for the running copy of the job instead of being skipped, which would leave a gap in the backfill
+	periodJob := job
+	if periodJob.ConcurrencyPolicy == model.ConcurrencyPolicySkip {
+		periodJob.ConcurrencyPolicy = model.ConcurrencyPolicyQueue
+	}
+	for _, period := range periods {
+		if ctx.Err() != nil {
+			break
+		}
+		run := newRun(job, period.Start, model.RunTriggerBackfill)
+		run.BackfillID = backfill.ID
+		result := a.execute(ctx, periodJob, run, func(_ context.Context, job model.CronJob, _ model.JobRun) (scheduler_strategy.Query, error) {
+			return strategy.GeneratePeriodQuery(job, period)
+		})
+		if result.Run.Status == model.RunStatusInterrupted {
+			backfill.Status = model.BackfillStatusInterrupted
+			backfill.Error = result.Run.Error
+			break
+		}
+		if result.Run.Status == model.RunStatusSucceeded {
+			backfill.CompletedPeriods++
+		} else if ctx.Err() == nil {
+			backfill.FailedPeriods++
+		}
+		a.updateBackfill(backfill)
+	}
+
+	switch {
+	case backfill.Status == model.BackfillStatusInterrupted:
+	case ctx.Err() != nil:
+		backfill.Status = model.BackfillStatusCancelled
+		backfill.Error = errBackfillCancelled.Error()
+	case backfill.FailedPeriods > 0:
+		backfill.Status = model.BackfillStatusFailed
+	default:
+		backfill.Status = model.BackfillStatusSucceeded
+	}
+	backfill.FinishedAt = time.Now().UTC().Format(helper.TimeLayout)
+	a.updateBackfill(backfill)
+	logger.Log.Info("Backfill finished", zap.String("job_name", job.Name), zap.String("backfill_id", backfill.ID),
+		zap.String("status", backfill.Status), zap.Int("completed_periods", backfill.CompletedPeriods),
+		zap.Int("failed_periods", backfill.FailedPeriods))
+}
+
+func (a *appScheduler) updateBackfill(backfill model.Backfill) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := a.repo.Backfill.UpdateBackfill(dbCtx, backfill)
+	if err != nil {
+		logger.Log.Error("Unable to record the progress of the backfill", zap.String("backfill_id", backfill.ID), zap.Error(err))
+	}
+}
+
+// interruptLeftRunningBackfills Marks the backfills which were running when the previous process stopped as
+// interrupted. They are not resumed, a new backfill has to be started for the remaining periods
+func (a *appScheduler) interruptLeftRunningBackfills(ctx context.Context) {
+	interrupted, err := a.repo.Backfill.InterruptRunningBackfills(ctx)
+	if err != nil {
+		logger.Log.Error("Unable to mark the unfinished backfills as interrupted", zap.Error(err))
+		return
+	}
+	if interrupted > 0 {
+		logger.Log.Warn("Found backfills which were left running", zap.Int64("backfills", interrupted))
+	}
+}
@@ -0,0 +1,122 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"scheduler/internal/db/model"
+	"sync"
+)
+
+// maxQueuedRuns Upper bound of the runs of a job which wait for a running copy under the queue policy. Further runs
+// are skipped, so a stuck run can not pile up waiting runs with every fire
+const maxQueuedRuns = 5
+
+var (
+	errPreviousRunInProgress = errors.New("previous run is still in progress")
+	errTooManyQueuedRuns     = errors.New("too many runs of the job are queued")
+)
+
+// jobRuns The runs of a job which are in progress or wait for their turn
+type jobRuns struct {
+	running int
+	queued  int
+	// finished is closed and replaced every time a run of the job finishes
+	finished chan struct{}
+}
+
+// jobRunRegistry Keeps the runs of every job which are in progress. It is keyed by job so the concurrency policy
+// holds for the fires of the cron entries which replace each other on an update or a resume, and for the runs which
+// do not come from the cron. It is shared by all the schedulers of the process
+type jobRunRegistry struct {
+	mu   sync.Mutex
+	jobs map[uint]*jobRuns
+}
+
+var runningJobs = newJobRunRegistry()
+
+func newJobRunRegistry() *jobRunRegistry {
+	return &jobRunRegistry{jobs: make(map[uint]*jobRuns)}
+}
+
+// runs Returns the runs of the job, the lock has to be held
+func (r *jobRunRegistry) runs(jobID uint) *jobRuns {
+	runs, ok := r.jobs[jobID]
+	if !ok {
+		runs = &jobRuns{finished: make(chan struct{})}
+		r.jobs[jobID] = runs
+	}
+	return runs
+}
+
+// enter Registers a run of the job according to its concurrency policy without waiting. Skip fails while another run
+// of the job is in progress and queue fails when maxQueuedRuns runs wait already. It reports whether the run is
+// queued, a queued run has to wait for its turn with admit. The run has to be ended once it is finished
+func (r *jobRunRegistry) enter(job model.CronJob) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	runs := r.runs(job.ID)
+	switch {
+	case runs.running == 0 && runs.queued == 0, job.ConcurrencyPolicy == model.ConcurrencyPolicyAllow,
+		job.ConcurrencyPolicy == "":
+		runs.running++
+		return false, nil
+	case job.ConcurrencyPolicy == model.ConcurrencyPolicySkip:
+		return false, errPreviousRunInProgress
+	case runs.queued >= maxQueuedRuns:
+		return false, errTooManyQueuedRuns
+	default:
+		runs.queued++
+		return true, nil
+	}
+}
+
+// admit Waits until no other run of the job is in progress and starts the queued run. It gives up the turn of the
+// run when the context is done first
+func (r *jobRunRegistry) admit(ctx context.Context, jobID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	runs := r.runs(jobID)
+	for runs.running > 0 && ctx.Err() == nil {
+		finished := runs.finished
+		r.mu.Unlock()
+		select {
+		case <-finished:
+		case <-ctx.Done():
+		}
+		r.mu.Lock()
+	}
+	runs.queued--
+	if ctx.Err() != nil {
+		r.release(jobID, runs)
+		return context.Cause(ctx)
+	}
+	runs.running++
+	return nil
+}
+
+// end Releases a run which was entered or admitted
+func (r *jobRunRegistry) end(jobID uint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	runs := r.runs(jobID)
+	runs.running--
+	r.release(jobID, runs)
+}
+
+// release Wakes up the queued runs of the job and forgets it when none of its runs is left, the lock has to be held
+func (r *jobRunRegistry) release(jobID uint, runs *jobRuns) {
+	close(runs.finished)
+	runs.finished = make(chan struct{})
+	if runs.running <= 0 && runs.queued <= 0 {
+		delete(r.jobs, jobID)
+	}
+}
+
+// skipRun Records a run which was skipped by the concurrency policy of the job
+func (a *appScheduler) skipRun(ctx context.Context, job model.CronJob, run model.JobRun, reason error) RunResult {
+	run.Status = model.RunStatusSkipped
+	run.FinishedAt = run.StartedAt
+	run.Error = reason.Error()
+	a.dispatcher.EmitSkipped(ctx, job, run)
+	return RunResult{Run: run}
+}
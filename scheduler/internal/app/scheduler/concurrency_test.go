@@ -0,0 +1,141 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"scheduler/internal/db/model"
+	"scheduler/internal/observer"
+	"testing"
+	"time"
+)
+
+func TestSkipPolicyHoldsAcrossDefinitionsOfTheJob(t *testing.T) {
+	registry := newJobRunRegistry()
+	// an update swaps the definition while a run of the previous one is in progress
+	previous := model.CronJob{ID: 1, ConcurrencyPolicy: model.ConcurrencyPolicyAllow}
+	updated := model.CronJob{ID: 1, ConcurrencyPolicy: model.ConcurrencyPolicySkip}
+
+	if _, err := registry.enter(previous); err != nil {
+		t.Fatalf("the first run was skipped: %v", err)
+	}
+	if _, err := registry.enter(updated); !errors.Is(err, errPreviousRunInProgress) {
+		t.Fatalf("the run of the updated job overlaps the run of the previous definition: %v", err)
+	}
+	if _, err := registry.enter(model.CronJob{ID: 2, ConcurrencyPolicy: model.ConcurrencyPolicySkip}); err != nil {
+		t.Fatalf("the run of another job was skipped: %v", err)
+	}
+	registry.end(previous.ID)
+	if _, err := registry.enter(updated); err != nil {
+		t.Fatalf("the run was skipped once the previous run finished: %v", err)
+	}
+}
+
+func TestQueuePolicyWaitsForTheRunningCopy(t *testing.T) {
+	registry := newJobRunRegistry()
+	job := model.CronJob{ID: 1, ConcurrencyPolicy: model.ConcurrencyPolicyQueue}
+	if queued, err := registry.enter(job); queued || err != nil {
+		t.Fatalf("the first run was queued: %v", err)
+	}
+
+	queued, err := registry.enter(job)
+	if !queued || err != nil {
+		t.Fatalf("the second run was not queued: %v", err)
+	}
+	started := make(chan error)
+	go func() {
+		started <- registry.admit(context.Background(), job.ID)
+	}()
+	select {
+	case <-started:
+		t.Fatal("the queued run started while the previous run is in progress")
+	case <-time.After(50 * time.Millisecond):
+	}
+	registry.end(job.ID)
+	select {
+	case err := <-started:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the queued run did not start once the previous run finished")
+	}
+}
+
+func TestQueuedRunsCanBeCancelledAndAreBounded(t *testing.T) {
+	registry := newJobRunRegistry()
+	job := model.CronJob{ID: 1, ConcurrencyPolicy: model.ConcurrencyPolicyQueue}
+	registry.enter(job)
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	registry.enter(job)
+	waited := make(chan error)
+	go func() {
+		waited <- registry.admit(ctx, job.ID)
+	}()
+	cancel(errRunCancelled)
+	select {
+	case err := <-waited:
+		if !errors.Is(err, errRunCancelled) {
+			t.Fatalf("the wait ended with %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the cancelled run kept waiting for the stuck run")
+	}
+
+	for i := 0; i < maxQueuedRuns; i++ {
+		if _, err := registry.enter(job); err != nil {
+			t.Fatalf("run %d was not queued: %v", i, err)
+		}
+	}
+	if _, err := registry.enter(job); !errors.Is(err, errTooManyQueuedRuns) {
+		t.Fatalf("the queue grew beyond its bound: %v", err)
+	}
+}
+
+func TestQueuedRunIsInProgressWhileItWaits(t *testing.T) {
+	a := &appScheduler{dispatcher: observer.NewJobEventDispatcher()}
+	job := model.CronJob{ID: 1001, ConcurrencyPolicy: model.ConcurrencyPolicyQueue}
+	// a stuck run of the job
+	runningJobs.enter(job)
+	defer runningJobs.end(job.ID)
+
+	run := newRun(job, time.Now(), model.RunTriggerManual)
+	_, execute := a.begin(context.Background(), job, run, nil)
+	finished := make(chan RunResult)
+	go func() {
+		finished <- execute()
+	}()
+	if err := a.CancelRun(job.ID, run.ID); err != nil {
+		t.Fatalf("the queued run can not be cancelled: %v", err)
+	}
+	select {
+	case result := <-finished:
+		if result.Run.Status != model.RunStatusCancelled {
+			t.Errorf("the cancelled run is %s", result.Run.Status)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the cancelled run kept waiting for the stuck run")
+	}
+}
+
+func TestTriggerReturnsTheRunOnceItIsRegistered(t *testing.T) {
+	a := &appScheduler{dispatcher: observer.NewJobEventDispatcher()}
+	job := model.CronJob{ID: 1002, ConcurrencyPolicy: model.ConcurrencyPolicySkip}
+	// a stuck run of the job
+	runningJobs.enter(job)
+	defer runningJobs.end(job.ID)
+
+	result := a.TriggerJob(context.Background(), job, false)
+	if result.Run.Status != model.RunStatusSkipped {
+		t.Errorf("the run skipped by the policy is returned as %s", result.Run.Status)
+	}
+
+	job.ConcurrencyPolicy = model.ConcurrencyPolicyQueue
+	result = a.TriggerJob(context.Background(), job, false)
+	if result.Run.Status != model.RunStatusRunning {
+		t.Errorf("the queued run is returned as %s", result.Run.Status)
+	}
+	if err := a.CancelRun(job.ID, result.Run.ID); err != nil {
+		t.Errorf("the returned run can not be cancelled right away: %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"scheduler/internal/app/scheduler_strategy"
+	"scheduler/internal/db/model"
+	"scheduler/internal/helper"
+	"scheduler/internal/logger"
+	"strconv"
+	"time"
+)
+
+// maxIncrementalBuckets Incremental runs whose new rows fall in more buckets than this recompute everything instead
+const maxIncrementalBuckets = 500
+
+// incrementalQuery Generates the query of an incremental run. The highest value of the watermark column is read
+// first, so rows which arrive while the run executes are left for the next run. Without a watermark the whole window
+// is recomputed, otherwise only the buckets of the rows after the watermark are. Every touched bucket is recomputed
+// from all its rows, so the posted delta holds complete aggregations
+func (a *appScheduler) incrementalQuery(ctx context.Context, job model.CronJob, run model.JobRun) (scheduler_strategy.Query, error) {
+	strategyImpl, err := scheduler_strategy.GetStrategy(job)
+	if err != nil {
+		return scheduler_strategy.Query{}, err
+	}
+	incremental, ok := strategyImpl.(scheduler_strategy.IncrementalStrategy)
+	if !ok {
+		return scheduler_strategy.Query{}, fmt.Errorf("duration does not support incremental runs: %s", job.Duration)
+	}
+
+	highest, err := a.highestWatermark(ctx, job)
+	if err != nil {
+		return scheduler_strategy.Query{}, err
+	}
+	if highest == nil {
+		// the table has no rows to aggregate yet
+		return scheduler_strategy.Query{Skip: true}, nil
+	}
+	next := newWatermark(job, run, highest)
+
+	previous, found, err := a.repo.Watermark.GetWatermark(ctx, job.ID)
+	if err != nil {
+		return scheduler_strategy.Query{}, err
+	}
+	if !found || previous.Column != job.WatermarkColumn {
+		return a.fullIncrementalQuery(job, run, next)
+	}
+	if previous.Value == next.Value {
+		return scheduler_strategy.Query{Skip: true}, nil
+	}
+
+	bucketsQuery, err := incremental.ChangedBucketsQuery(job, watermarkValue(previous), watermarkValue(next))
+	if err != nil {
+		return scheduler_strategy.Query{}, err
+	}
+	rows, err := a.repo.DataSource.ExecuteRawQuery(ctx, job.DataSource, bucketsQuery.Text, bucketsQuery.Args...)
+	if err != nil {
+		return scheduler_strategy.Query{}, err
+	}
+	if len(rows) == 0 {
+		return scheduler_strategy.Query{Skip: true, Watermark: &next}, nil
+	}
+	if len(rows) > maxIncrementalBuckets {
+		return a.fullIncrementalQuery(job, run, next)
+	}
+	buckets := make([]interface{}, 0, len(rows))
+	for _, row := range rows {
+		buckets = append(buckets, scheduler_strategy.RowValue(row[scheduler_strategy.BucketAlias]))
+	}
+	logger.Log.Debug("Incremental run", zap.String("job_name", job.Name), zap.String("watermark", previous.Value),
+		zap.Int("buckets", len(buckets)))
+	query, err := incremental.GenerateBucketsQuery(job, buckets)
+	if err != nil {
+		return scheduler_strategy.Query{}, err
+	}
+	query.Watermark = &next
+	return query, nil
+}
+
+// fullIncrementalQuery Generates the query which recomputes the whole window and moves the watermark to next
+func (a *appScheduler) fullIncrementalQuery(job model.CronJob, run model.JobRun, next model.Watermark) (scheduler_strategy.Query, error) {
+	query, err := a.fullQuery(job, run)
+	if err != nil {
+		return scheduler_strategy.Query{}, err
+	}
+	query.Watermark = &next
+	return query, nil
+}
+
+// highestWatermark Returns the highest value of the watermark column among the rows the job aggregates, nil when
+// there are none
+func (a *appScheduler) highestWatermark(ctx context.Context, job model.CronJob) (interface{}, error) {
+	query, err := scheduler_strategy.WatermarkQuery(job)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := a.repo.DataSource.ExecuteRawQuery(ctx, job.DataSource, query.Text, query.Args...)
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	return scheduler_strategy.RowValue(rows[0][scheduler_strategy.WatermarkAlias]), nil
+}
+
+// saveWatermark Stores the watermark of an incremental run once its result is delivered. A run which fails to store
+// it fails, and the next run posts the same buckets again
+func (a *appScheduler) saveWatermark(ctx context.Context, watermark *model.Watermark) error {
+	if watermark == nil {
+		return nil
+	}
+	watermark.UpdatedAt = time.Now().UTC().Format(helper.TimeLayout)
+	err := a.repo.Watermark.SaveWatermark(ctx, *watermark)
+	if err != nil {
+		return fmt.Errorf("unable to save the watermark: %w", err)
+	}
+	return nil
+}
+
+// newWatermark Returns the watermark of the job at the given value of its watermark column
+func newWatermark(job model.CronJob, run model.JobRun, value interface{}) model.Watermark {
+	watermark := model.Watermark{JobID: job.ID, Column: job.WatermarkColumn, RunID: run.ID}
+	switch v := value.(type) {
+	case int64:
+		watermark.Value, watermark.Numeric = strconv.FormatInt(v, 10), true
+	case float64:
+		watermark.Value, watermark.Numeric = strconv.FormatFloat(v, 'g', -1, 64), true
+	case []byte:
+		watermark.Value = string(v)
+	case time.Time:
+		watermark.Value = v.Format(helper.TimeLayout)
+	default:
+		watermark.Value = fmt.Sprint(v)
+	}
+	return watermark
+}
+
+// watermarkValue Returns the value of the watermark to bind to the queries. Numbers are bound as numbers so they
+// compare with the column as numbers
+func watermarkValue(watermark model.Watermark) interface{} {
+	if !watermark.Numeric {
+		return watermark.Value
+	}
+	if n, err := strconv.ParseInt(watermark.Value, 10, 64); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(watermark.Value, 64); err == nil {
+		return f
+	}
+	return watermark.Value
+}
@@ -0,0 +1,82 @@
+package scheduler
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"scheduler/internal/db/model"
+	"scheduler/internal/helper"
+	"scheduler/internal/logger"
+	"time"
+)
+
+// maxCatchUpRuns Upper bound of missed periods which are recovered for a single job on boot
+const maxCatchUpRuns = 100
+
+// missedRuns Returns the most recent fire times (at most limit, oldest first) between the persisted next run of the
+// job and now, together with the number of missed fire times. The fire times are searched backwards from now in
+// windows which double until they hold limit fire times or reach the next run, so the cost grows with the limit and
+// only logarithmically with how long the service was down. The number is the exact total when the search reached the
+// next run and a lower bound otherwise
+func missedRuns(job model.CronJob, now time.Time, limit int) ([]time.Time, int, error) {
+	nextRun, err := helper.ParseStoredTime(job.NextRun)
+	if err != nil || nextRun.After(now) || limit <= 0 {
+		return nil, 0, nil
+	}
+	schedule, err := helper.ParseCronExpression(job.CronExpression)
+	if err != nil {
+		return nil, 0, err
+	}
+	location := helper.JobLocation(job.Timezone)
+	for window := time.Minute; ; window *= 2 {
+		from := now.Add(-window)
+		if !from.After(nextRun) {
+			from = nextRun
+		}
+		// the next run is a fire time itself, the fire times of the other windows start after their start
+		var missed []time.Time
+		fireTime := from.In(location)
+		if from != nextRun {
+			fireTime = schedule.Next(fireTime)
+		}
+		for ; !fireTime.After(now); fireTime = schedule.Next(fireTime) {
+			missed = append(missed, fireTime)
+		}
+		if from == nextRun {
+			return missed[max(len(missed)-limit, 0):], len(missed), nil
+		}
+		if len(missed) >= limit {
+			// the next run is missed as well
+			return missed[len(missed)-limit:], len(missed) + 1, nil
+		}
+	}
+}
+
+// recoverMissedRuns Applies the misfire policy of the job to the fire times which were missed while the service was down
+func (a *appScheduler) recoverMissedRuns(ctx context.Context, job model.CronJob, now time.Time) {
+	limit := 0
+	switch job.MisfirePolicy {
+	case model.MisfirePolicyRunOnce:
+		limit = 1
+	case model.MisfirePolicyRunAll:
+		limit = maxCatchUpRuns
+	default:
+		nextRun, err := helper.ParseStoredTime(job.NextRun)
+		if err == nil && !nextRun.After(now) {
+			logger.Log.Info("Ignoring missed runs", zap.String("job_name", job.Name), zap.String("since", job.NextRun))
+		}
+		return
+	}
+
+	missed, total, err := missedRuns(job, now, limit)
+	if err != nil {
+		logger.Log.Error("Unable to determine the missed runs of the job", zap.String("job_name", job.Name), zap.Error(err))
+		return
+	}
+	if total > len(missed) {
+		logger.Log.Warn("Only recovering the most recent missed runs", zap.String("job_name", job.Name),
+			zap.Int("missed_at_least", total), zap.Int("recovered", len(missed)))
+	}
+	for _, scheduledAt := range missed {
+		a.execute(ctx, job, newRun(job, scheduledAt, model.RunTriggerCatchUp), a.scheduledQuery)
+	}
+}
@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"scheduler/internal/db/model"
+	"scheduler/internal/helper"
+	"slices"
+	"testing"
+	"time"
+)
+
+// allMissedRuns Returns every fire time between the next run of the job and now
+func allMissedRuns(t *testing.T, job model.CronJob, now time.Time) []time.Time {
+	nextRun, _ := helper.ParseStoredTime(job.NextRun)
+	schedule, err := helper.ParseCronExpression(job.CronExpression)
+	if err != nil {
+		t.Fatalf("invalid cron expression: %v", err)
+	}
+	var missed []time.Time
+	for fireTime := nextRun.In(time.UTC); !fireTime.After(now); fireTime = schedule.Next(fireTime) {
+		missed = append(missed, fireTime)
+	}
+	return missed
+}
+
+func TestMissedRunsAreTheMostRecentFireTimes(t *testing.T) {
+	now := time.Date(2025, 5, 7, 21, 17, 30, 0, time.UTC)
+	job := model.CronJob{CronExpression: "*/7 9-17 * * 1-5", Timezone: "UTC", NextRun: "2025-04-01 09:00:00"}
+	all := allMissedRuns(t, job, now)
+
+	for _, limit := range []int{1, 10, maxCatchUpRuns, len(all), len(all) + 10} {
+		missed, total, err := missedRuns(job, now, limit)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := all[max(len(all)-limit, 0):]
+		if !slices.Equal(missed, want) {
+			t.Errorf("limit %d: missed %d fire times from %v, want %d from %v", limit, len(missed), missed[0], len(want), want[0])
+		}
+		if total > len(all) || (limit >= len(all) && total != len(all)) || (limit < len(all) && total <= len(missed)) {
+			t.Errorf("limit %d: counted %d of %d missed fire times", limit, total, len(all))
+		}
+	}
+}
+
+func TestMissedRunsAfterAMonthsLongDowntimeAreFoundQuickly(t *testing.T) {
+	now := time.Date(2025, 5, 7, 21, 17, 30, 0, time.UTC)
+	job := model.CronJob{CronExpression: "* * * * *", Timezone: "UTC", NextRun: "2020-01-01 00:00:00"}
+
+	started := time.Now()
+	missed, total, err := missedRuns(job, now, maxCatchUpRuns)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(missed) != maxCatchUpRuns || !missed[len(missed)-1].Equal(now.Truncate(time.Minute)) {
+		t.Errorf("missed %d fire times up to %v", len(missed), missed[len(missed)-1])
+	}
+	if total <= len(missed) {
+		t.Errorf("counted %d missed fire times", total)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("searching the missed fire times took %s", elapsed)
+	}
+}
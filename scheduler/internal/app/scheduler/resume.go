@@ -0,0 +1,51 @@
+package scheduler
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"scheduler/internal/db/model"
+	"scheduler/internal/helper"
+	"scheduler/internal/logger"
+)
+
+// resumeInterruptedRuns Runs the active jobs again for the periods of the runs which were interrupted by the previous
+// shutdown. Runs which were left running by a crash are treated as interrupted as well
+func (a *appScheduler) resumeInterruptedRuns(ctx context.Context, jobs []model.CronJob) {
+	leftRunning, err := a.repo.JobRun.InterruptRunningRuns(ctx)
+	if err != nil {
+		logger.Log.Error("Unable to mark the unfinished runs as interrupted", zap.Error(err))
+		return
+	}
+	if leftRunning > 0 {
+		logger.Log.Warn("Found runs which were left running", zap.Int64("runs", leftRunning))
+	}
+	runs, err := a.repo.JobRun.GetRunsToResume(ctx)
+	if err != nil {
+		logger.Log.Error("Unable to load the interrupted runs", zap.Error(err))
+		return
+	}
+
+	activeJobs := make(map[uint]model.CronJob)
+	for _, job := range jobs {
+		activeJobs[job.ID] = job
+	}
+	for _, interrupted := range runs {
+		job, ok := activeJobs[interrupted.JobID]
+		if !ok {
+			continue
+		}
+		scheduledAt, err := helper.ParseStoredTime(interrupted.ScheduledAt)
+		if err != nil {
+			logger.Log.Error("Unable to resume the interrupted run", zap.String("run_id", interrupted.ID), zap.Error(err))
+			continue
+		}
+		run := newRun(job, scheduledAt, model.RunTriggerResume)
+		err = a.repo.JobRun.SetResumedBy(ctx, interrupted.ID, run.ID)
+		if err != nil {
+			logger.Log.Error("Unable to resume the interrupted run", zap.String("run_id", interrupted.ID), zap.Error(err))
+			continue
+		}
+		logger.Log.Info("Resuming interrupted run", zap.String("job_name", job.Name), zap.String("run_id", interrupted.ID))
+		go a.execute(context.Background(), job, run, a.scheduledQuery)
+	}
+}
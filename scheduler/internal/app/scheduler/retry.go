@@ -0,0 +1,85 @@
+package scheduler
+
+import (
+	"context"
+	"math"
+	"scheduler/internal/db/model"
+	"slices"
+	"time"
+)
+
+const (
+	defaultRetryMaxAttempts    = 1
+	defaultRetryInitialBackoff = time.Second
+	defaultRetryMultiplier     = 2.0
+	defaultRetryMaxBackoff     = 30 * time.Second
+)
+
+var defaultRetryableStatuses = []int{408, 429, 500, 502, 503, 504}
+
+// retryPolicy Decides how often and how fast the delivery of a result is retried
+type retryPolicy struct {
+	maxAttempts       int
+	initialBackoff    time.Duration
+	multiplier        float64
+	maxBackoff        time.Duration
+	retryableStatuses []int
+}
+
+// retryPolicyFromJob Reads the retry policy of the job, using the defaults for anything which is not set
+func retryPolicyFromJob(job model.CronJob) retryPolicy {
+	policy := retryPolicy{
+		maxAttempts:       job.RetryMaxAttempts,
+		initialBackoff:    parseDurationOrDefault(job.RetryInitialBackoff, defaultRetryInitialBackoff),
+		multiplier:        job.RetryMultiplier,
+		maxBackoff:        parseDurationOrDefault(job.RetryMaxBackoff, defaultRetryMaxBackoff),
+		retryableStatuses: job.RetryableStatuses,
+	}
+	if policy.maxAttempts <= 0 {
+		policy.maxAttempts = defaultRetryMaxAttempts
+	}
+	if policy.multiplier < 1 {
+		policy.multiplier = defaultRetryMultiplier
+	}
+	if len(policy.retryableStatuses) == 0 {
+		policy.retryableStatuses = defaultRetryableStatuses
+	}
+	return policy
+}
+
+func parseDurationOrDefault(value string, fallback time.Duration) time.Duration {
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return fallback
+	}
+	return duration
+}
+
+// backoff Returns the time to wait after the given attempt (starting at 1) failed
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	backoff := float64(p.initialBackoff) * math.Pow(p.multiplier, float64(attempt-1))
+	if backoff > float64(p.maxBackoff) {
+		return p.maxBackoff
+	}
+	return time.Duration(backoff)
+}
+
+// shouldRetry Decides whether a failed attempt is retried. Failures without a response are always retryable
+func (p retryPolicy) shouldRetry(attempt int, httpStatus int) bool {
+	if attempt >= p.maxAttempts {
+		return false
+	}
+	return httpStatus == 0 || slices.Contains(p.retryableStatuses, httpStatus)
+}
+
+// wait Sleeps for the backoff unless the context is done first
+func wait(ctx context.Context, backoff time.Duration) error {
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
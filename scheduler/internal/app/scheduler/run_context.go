@@ -0,0 +1,141 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"scheduler/internal/db/model"
+	"sync"
+	"time"
+)
+
+// defaultMaxRuntime Max runtime of jobs which do not configure their own
+const defaultMaxRuntime = 10 * time.Minute
+
+var (
+	errRunCancelled   = errors.New("run was cancelled")
+	errRunTimedOut    = errors.New("run exceeded its max runtime")
+	errRunInterrupted = errors.New("run was interrupted by a shutdown")
+)
+
+// interruptTimeout How long the shutdown waits for interrupted runs to record their status
+const interruptTimeout = 5 * time.Second
+
+// inFlightRun A run which is executing right now
+type inFlightRun struct {
+	jobID  uint
+	cancel context.CancelCauseFunc
+}
+
+// runRegistry Keeps the cancel functions of the in-flight runs. It is shared by all the schedulers of the process
+type runRegistry struct {
+	mu   sync.Mutex
+	runs map[string]inFlightRun
+	// draining is set once the shutdown started. Runs which start afterwards are interrupted right away
+	draining bool
+}
+
+var activeRuns = &runRegistry{runs: make(map[string]inFlightRun)}
+
+// start Creates the context of a run, bounded by the max runtime of the job, and registers it so it can be cancelled.
+// The returned function releases the context and must be called once the run is finished
+func (r *runRegistry) start(parent context.Context, job model.CronJob, runID string) (context.Context, context.CancelFunc) {
+	maxRuntime := maxRuntimeOfJob(job)
+	ctx, cancelCause := context.WithCancelCause(parent)
+	ctx, cancelTimeout := context.WithTimeoutCause(ctx, maxRuntime, fmt.Errorf("%w of %s", errRunTimedOut, maxRuntime))
+
+	r.mu.Lock()
+	r.runs[runID] = inFlightRun{jobID: job.ID, cancel: cancelCause}
+	if r.draining {
+		cancelCause(errRunInterrupted)
+	}
+	r.mu.Unlock()
+
+	return ctx, func() {
+		r.mu.Lock()
+		delete(r.runs, runID)
+		r.mu.Unlock()
+		cancelTimeout()
+		cancelCause(nil)
+	}
+}
+
+// cancel Aborts an in-flight run of the job. It returns false when the run is not in progress
+func (r *runRegistry) cancel(jobID uint, runID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run, ok := r.runs[runID]
+	if !ok || run.jobID != jobID {
+		return false
+	}
+	run.cancel(errRunCancelled)
+	return true
+}
+
+// count Returns the number of in-flight runs
+func (r *runRegistry) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.runs)
+}
+
+// interruptAll Aborts all the in-flight runs and returns how many were aborted
+func (r *runRegistry) interruptAll() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, run := range r.runs {
+		run.cancel(errRunInterrupted)
+	}
+	return len(r.runs)
+}
+
+// waitIdle Waits until no run is in flight or the context is done. It returns false when runs are still in flight
+func (r *runRegistry) waitIdle(ctx context.Context) bool {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for r.count() > 0 {
+		select {
+		case <-ctx.Done():
+			return r.count() == 0
+		case <-ticker.C:
+		}
+	}
+	return true
+}
+
+// DrainRuns Waits for the in-flight runs of the process to finish until the context is done. The runs which are
+// still in flight then are interrupted, which records them as interrupted so that they are resumed on the next boot.
+// It returns the number of interrupted runs
+func DrainRuns(ctx context.Context) int {
+	activeRuns.mu.Lock()
+	activeRuns.draining = true
+	activeRuns.mu.Unlock()
+
+	if activeRuns.waitIdle(ctx) {
+		return 0
+	}
+	interrupted := activeRuns.interruptAll()
+	waitCtx, cancel := context.WithTimeout(context.Background(), interruptTimeout)
+	defer cancel()
+	activeRuns.waitIdle(waitCtx)
+	return interrupted
+}
+
+func maxRuntimeOfJob(job model.CronJob) time.Duration {
+	return parseDurationOrDefault(job.MaxRuntime, defaultMaxRuntime)
+}
+
+// statusOfFailedRun Returns the status of a run which ended with an error together with the error to report
+func statusOfFailedRun(ctx context.Context, err error) (string, error) {
+	cause := context.Cause(ctx)
+	switch {
+	case errors.Is(cause, errRunCancelled), errors.Is(cause, errBackfillCancelled):
+		return model.RunStatusCancelled, cause
+	case errors.Is(cause, errRunTimedOut):
+		return model.RunStatusTimedOut, cause
+	case errors.Is(cause, errRunInterrupted):
+		return model.RunStatusInterrupted, cause
+	default:
+		return model.RunStatusFailed, err
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"scheduler/config"
 	"scheduler/internal/app/scheduler_strategy"
+	"scheduler/internal/clock"
 	"scheduler/internal/db/model"
 	"scheduler/internal/helper"
 	"scheduler/internal/local_cron"
@@ -23,7 +25,18 @@ type AppScheduler interface {
 	LoadAndScheduleJobs(context.Context) error
 	AddJob(ctx context.Context, job model.CronJob) error
 	RemoveJob(uint) error
-	ExecuteJob(context.Context, model.CronJob)
+	ExecuteJob(ctx context.Context, job model.CronJob, scheduledAt time.Time) RunResult
+	TriggerJob(ctx context.Context, job model.CronJob, wait bool) RunResult
+	CancelRun(jobID uint, runID string) error
+	Backfill(ctx context.Context, job model.CronJob, start time.Time, end time.Time) (model.Backfill, error)
+	CancelBackfill(jobID uint, backfillID string) error
+}
+
+// RunResult The outcome of a single execution of a job
+type RunResult struct {
+	Run          model.JobRun
+	Query        string
+	SinkResponse []byte
 }
 
 // structure to hold the injected object
@@ -31,13 +44,15 @@ type appScheduler struct {
 	repo       *repository.Repository
 	cron       *local_cron.LocalCron
 	dispatcher *observer.JobEventDispatcher
+	clock      clock.Clock
 }
 
 // NewAppScheduler Inject the objects in the structure
 func NewAppScheduler(repo *repository.Repository) AppScheduler {
 	d := observer.NewJobEventDispatcher()
 	d.RegisterListener(&observer.LoggingListener{})
-	return &appScheduler{repo: repo, cron: local_cron.GetCron(), dispatcher: d}
+	d.RegisterListener(observer.NewRunHistoryListener(repo.JobRun))
+	return &appScheduler{repo: repo, cron: local_cron.GetCron(), dispatcher: d, clock: clock.GetClock()}
 }
 
 func (a *appScheduler) Boot(ctx context.Context) error {
@@ -45,30 +60,39 @@ func (a *appScheduler) Boot(ctx context.Context) error {
 	return a.LoadAndScheduleJobs(ctx)
 }
 
-// LoadAndScheduleJobs Fetching the jobs from database and adding it to the scheduler when service is up
+// LoadAndScheduleJobs Fetching the jobs from database and adding it to the scheduler when service is up.
+// The runs interrupted by the previous shutdown are resumed and the fire times missed while the service was down are
+// recovered in the background according to the misfire policy of the job
 func (a *appScheduler) LoadAndScheduleJobs(ctx context.Context) error {
-	jobs, err := a.repo.Job.GetAllEnabledJobs(ctx)
+	jobs, err := a.repo.Job.GetAllActiveJobs(ctx)
 	if err != nil {
 		return err
 	}
+	a.interruptLeftRunningBackfills(ctx)
+	a.resumeInterruptedRuns(ctx, jobs)
+	now := time.Now()
 	for _, job := range jobs {
 		err := a.AddJob(ctx, job)
 		if err != nil {
 			logger.Log.Error("Error adding the job to scheduler", zap.String("job_name", job.Name))
+			continue
 		}
+		go a.recoverMissedRuns(context.Background(), job, now)
 	}
 	return nil
 }
 
-// AddJob Function to add the job to the scheduler. When a new job is created via api it is added to the cron
+// AddJob Function to add the job to the scheduler. When a new job is created via api it is added to the cron.
+// A job which is already scheduled is swapped atomically to the new definition. Every fire gets its own context
 func (a *appScheduler) AddJob(ctx context.Context, job model.CronJob) error {
-	entryID, err := a.cron.Cron.AddFunc(job.CronExpression, func() {
-		a.ExecuteJob(ctx, job)
-	})
+	execute := func() {
+		a.ExecuteJob(context.Background(), job, time.Now().Truncate(time.Second))
+	}
+	spec := helper.ScheduleSpec(job.CronExpression, job.Timezone)
+	err := a.cron.ScheduleJob(job.ID, spec, execute)
 	if err != nil {
 		return err
 	}
-	a.cron.JobMap[job.ID] = entryID
 	err = a.UpdateNextRun(job)
 	if err != nil {
 		logger.Log.Error("Unable to update the next run for the job", zap.String("job_name", job.Name))
@@ -78,12 +102,9 @@ func (a *appScheduler) AddJob(ctx context.Context, job model.CronJob) error {
 
 // RemoveJob Removing the job from the scheduler
 func (a *appScheduler) RemoveJob(jobID uint) error {
-	entryID, ok := a.cron.JobMap[jobID]
-	if !ok {
+	if !a.cron.UnscheduleJob(jobID) {
 		return exception.NotScheduledJob
 	}
-	a.cron.Cron.Remove(entryID)
-	delete(a.cron.JobMap, jobID)
 	return nil
 }
 
@@ -91,12 +112,13 @@ func (a *appScheduler) RemoveJob(jobID uint) error {
 func (a *appScheduler) UpdateNextRun(job model.CronJob) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	nextRun, err := helper.GetNextRun(job.CronExpression)
+	nextRun, err := helper.GetNextRun(job.CronExpression, helper.JobLocation(job.Timezone))
 	if err != nil {
 		logger.Log.Error("Unable to get the next run", zap.String("job_name", job.Name))
+		return err
 	}
 	updates := map[string]interface{}{
-		"next_run": nextRun.Format("2006-01-02 15:04:05"),
+		"next_run": nextRun.UTC().Format(helper.TimeLayout),
 	}
 	err = a.repo.Job.UpdateJob(ctx, job, updates)
 	if err != nil {
@@ -110,7 +132,7 @@ func (a *appScheduler) UpdateLastRun(job model.CronJob) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	updates := map[string]interface{}{
-		"last_run": time.Now().Format("2006-01-02 15:04:05"),
+		"last_run": time.Now().UTC().Format(helper.TimeLayout),
 	}
 	err := a.repo.Job.UpdateJob(ctx, job, updates)
 	if err != nil {
@@ -120,48 +142,223 @@ func (a *appScheduler) UpdateLastRun(job model.CronJob) error {
 }
 
 // ExecuteJob Function to execute the job. Currently part of the scheduler app. It can be moved out if different kind of jobs are to be executed
-func (a *appScheduler) ExecuteJob(ctx context.Context, job model.CronJob) {
-	//logger.Log.Info("Executing job", zap.String("job_name", job.Name))
-	a.dispatcher.EmitStarted(ctx, job)
+// Every execution is recorded as a JobRun which is handed to the observers and returned once the run is finished
+func (a *appScheduler) ExecuteJob(ctx context.Context, job model.CronJob, scheduledAt time.Time) RunResult {
+	return a.execute(ctx, job, newRun(job, scheduledAt, model.RunTriggerSchedule), a.scheduledQuery)
+}
+
+// TriggerJob Runs the job immediately, outside of its schedule. Without waiting the run is returned once it is
+// recorded and can be looked up and cancelled, skipped when the concurrency policy of the job skipped it. The run gets
+// its own context, it does not inherit the context of the caller
+func (a *appScheduler) TriggerJob(ctx context.Context, job model.CronJob, wait bool) RunResult {
+	run := newRun(job, time.Now(), model.RunTriggerManual)
+	result, execute := a.begin(context.Background(), job, run, a.scheduledQuery)
+	if execute == nil {
+		return result
+	}
+	if !wait {
+		go execute()
+		return result
+	}
+	return execute()
+}
+
+// CancelRun Aborts an in-flight run of the job
+func (a *appScheduler) CancelRun(jobID uint, runID string) error {
+	if !activeRuns.cancel(jobID, runID) {
+		return exception.RunNotInProgressError
+	}
+	return nil
+}
+
+// newRun Creates the record of a run which is about to start
+func newRun(job model.CronJob, scheduledAt time.Time, trigger string) model.JobRun {
+	return model.JobRun{
+		ID:          uuid.NewString(),
+		JobID:       job.ID,
+		Trigger:     trigger,
+		ScheduledAt: scheduledAt.UTC().Format(helper.TimeLayout),
+		StartedAt:   time.Now().UTC().Format(helper.TimeLayout),
+		Status:      model.RunStatusRunning,
+	}
+}
+
+// queryGenerator Generates the query of a run
+type queryGenerator func(ctx context.Context, job model.CronJob, run model.JobRun) (scheduler_strategy.Query, error)
+
+// execute Runs the query of the job for the scheduled time of the run and returns the finished run
+func (a *appScheduler) execute(parent context.Context, job model.CronJob, run model.JobRun, generate queryGenerator) RunResult {
+	result, execute := a.begin(parent, job, run, generate)
+	if execute == nil {
+		return result
+	}
+	return execute()
+}
+
+// begin Registers the run so it can be cancelled and drained and applies the concurrency policy of the job, which
+// holds for every run, whether it is fired by the cron, triggered, recovered, resumed or a period of a backfill. A
+// skipped run is recorded right away and returned without a function to execute. Otherwise the run is recorded as
+// started and the returned function waits for the turn of a queued run, runs it and returns the finished run. The
+// max runtime of a queued run includes its wait, and the wait can be cancelled like the run
+func (a *appScheduler) begin(parent context.Context, job model.CronJob, run model.JobRun, generate queryGenerator) (RunResult, func() RunResult) {
+	queued, err := runningJobs.enter(job)
+	if err != nil {
+		return a.skipRun(parent, job, run, err), nil
+	}
+	ctx, release := activeRuns.start(parent, job, run.ID)
+	a.dispatcher.EmitStarted(ctx, job, run)
+	return RunResult{Run: run}, func() RunResult {
+		defer release()
+		if queued {
+			err := runningJobs.admit(ctx, job.ID)
+			if err != nil {
+				return a.failRun(ctx, job, RunResult{Run: run}, err)
+			}
+		}
+		defer runningJobs.end(job.ID)
+		a.updateSchedule(job, run)
+		return a.executeQuery(ctx, job, run, generate)
+	}
+}
+
+// updateSchedule Records the last and the next run of the job for the runs which stand for a fire of its schedule.
+// Manual runs, resumed runs and the periods of backfills leave them alone
+func (a *appScheduler) updateSchedule(job model.CronJob, run model.JobRun) {
+	if run.Trigger != model.RunTriggerSchedule && run.Trigger != model.RunTriggerCatchUp {
+		return
+	}
 	err := a.UpdateLastRun(job)
-	err = a.UpdateNextRun(job)
 	if err != nil {
 		logger.Log.Error("Unable to update the last run for the job", zap.String("job_name", job.Name))
 	}
-	strategyImpl, err := scheduler_strategy.GetStrategy(job.Duration)
+	err = a.UpdateNextRun(job)
 	if err != nil {
-		logger.Log.Error("Duration of Job is unknown")
-		return
+		logger.Log.Error("Unable to update the next run for the job", zap.String("job_name", job.Name))
 	}
+}
 
-	query, err := strategyImpl.GenerateQuery(job)
+// executeQuery Runs the job within the context of the run which is cancelled when the run is cancelled or exceeds the
+// max runtime of the job
+func (a *appScheduler) executeQuery(ctx context.Context, job model.CronJob, run model.JobRun, generate queryGenerator) RunResult {
+	result := RunResult{Run: run}
+	data, err := a.runJob(ctx, job, generate, &result)
 	if err != nil {
-		logger.Log.Error("Error generating query")
+		return a.failRun(ctx, job, result, err)
 	}
-	data := a.repo.Job.ExecuteRawQuery(query)
-	if data == nil {
-		a.dispatcher.EmitCompleted(ctx, job, nil)
-		//logger.Log.Info("No data found for the query")
-	} else {
-		result := map[string]interface{}{
-			"result": data,
+	result.Run.FinishedAt = time.Now().UTC().Format(helper.TimeLayout)
+	result.Run.Status = model.RunStatusSucceeded
+	a.dispatcher.EmitCompleted(ctx, job, result.Run, data)
+	return result
+}
+
+// failRun Records a run which ended with an error. The status tells a failure apart from a cancellation, a timeout
+// and an interruption
+func (a *appScheduler) failRun(ctx context.Context, job model.CronJob, result RunResult, err error) RunResult {
+	result.Run.FinishedAt = time.Now().UTC().Format(helper.TimeLayout)
+	result.Run.Status, err = statusOfFailedRun(ctx, err)
+	result.Run.Error = err.Error()
+	a.dispatcher.EmitFailed(ctx, job, result.Run, err)
+	return result
+}
+
+// runJob Generates and executes the query of the job and posts the result. The query, row count and the response of the api are recorded on the result.
+// The watermark of an incremental run is saved once its result is delivered
+func (a *appScheduler) runJob(ctx context.Context, job model.CronJob, generate queryGenerator, result *RunResult) ([]map[string]interface{}, error) {
+	query, err := generate(ctx, job, result.Run)
+	if err != nil {
+		return nil, err
+	}
+	if query.Skip {
+		return nil, a.saveWatermark(ctx, query.Watermark)
+	}
+	result.Query = query.Text
+	data, err := a.repo.DataSource.ExecuteRawQuery(ctx, job.DataSource, query.Text, query.Args...)
+	if err != nil {
+		return nil, err
+	}
+	err = query.PostAggregate(data, func(text string, args []interface{}, each func([]interface{}) error) error {
+		return a.repo.DataSource.StreamRawQuery(ctx, job.DataSource, each, text, args...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Run.RowCount = len(data)
+	if len(data) == 0 {
+		return data, a.saveWatermark(ctx, query.Watermark)
+	}
+
+	payload := map[string]interface{}{
+		"result": data,
+	}
+	err = a.deliverResult(ctx, job, payload, result)
+	if err != nil {
+		return nil, err
+	}
+	return data, a.saveWatermark(ctx, query.Watermark)
+}
+
+// scheduledQuery Generates the query of the strategy of the job for the window of the scheduled time of the run.
+// Incremental jobs only recompute what changed since their watermark
+func (a *appScheduler) scheduledQuery(ctx context.Context, job model.CronJob, run model.JobRun) (scheduler_strategy.Query, error) {
+	if job.WatermarkColumn != "" {
+		return a.incrementalQuery(ctx, job, run)
+	}
+	return a.fullQuery(job, run)
+}
+
+// fullQuery Generates the query of the strategy of the job which recomputes the whole window of the run
+func (a *appScheduler) fullQuery(job model.CronJob, run model.JobRun) (scheduler_strategy.Query, error) {
+	strategyImpl, err := scheduler_strategy.GetStrategy(job)
+	if err != nil {
+		return scheduler_strategy.Query{}, err
+	}
+	scheduledAt, err := helper.ParseStoredTime(run.ScheduledAt)
+	if err != nil {
+		return scheduler_strategy.Query{}, err
+	}
+	return strategyImpl.GenerateQuery(job, a.referenceNow(job, scheduledAt))
+}
+
+// referenceNow Returns the "now" the time window of a run is computed from, as a wall time in the timezone of the job
+// since the data of the table is stored without a timezone
+func (a *appScheduler) referenceNow(job model.CronJob, scheduledAt time.Time) time.Time {
+	return a.clock.ReferenceNow(scheduledAt).In(helper.JobLocation(job.Timezone))
+}
+
+// deliverResult Posts the result to the api, retrying according to the retry policy of the job. Every attempt is emitted to the observers
+func (a *appScheduler) deliverResult(ctx context.Context, job model.CronJob, payload interface{}, result *RunResult) error {
+	policy := retryPolicyFromJob(job)
+	for attempt := 1; ; attempt++ {
+		resp, err := PostResult(ctx, payload)
+		delivery := observer.DeliveryAttempt{Number: attempt, Err: err}
+		result.Run.Attempts = attempt
+		result.Run.HttpStatus = 0
+		result.SinkResponse = nil
+		if resp != nil {
+			delivery.HttpStatus = resp.StatusCode
+			result.Run.HttpStatus = resp.StatusCode
+			result.SinkResponse = resp.Body
 		}
-		err = PostResult(result)
-		if err != nil {
-			a.dispatcher.EmitFailed(ctx, job, err)
-			//logger.Log.Error("Unable to send the data")
+		if err == nil || !policy.shouldRetry(attempt, delivery.HttpStatus) {
+			a.dispatcher.EmitDeliveryAttempt(ctx, job, result.Run, delivery)
+			return err
+		}
+		delivery.Backoff = policy.backoff(attempt)
+		a.dispatcher.EmitDeliveryAttempt(ctx, job, result.Run, delivery)
+		if wait(ctx, delivery.Backoff) != nil {
+			return err
 		}
 	}
 }
 
-// PostResult Function to call the api to post result
-func PostResult(data interface{}) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// PostResult Function to call the api to post result. A single call is bounded to 10 seconds within the context of the run
+func PostResult(ctx context.Context, data interface{}) (*transport.HttpResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 	requestConfig := config.GetConfig().PostResult
 	body, err := json.Marshal(data)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	req := transport.HttpRequest{
 		HttpClient: transport.NewHTTPClient(),
@@ -172,9 +369,5 @@ func PostResult(data interface{}) error {
 		},
 		Body: body,
 	}
-	err = transport.RequestAndParseJSONBody(ctx, req)
-	if err != nil {
-		return err
-	}
-	return nil
+	return transport.RequestAndParseJSONBody(ctx, req)
 }
@@ -0,0 +1,53 @@
+package scheduler
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"scheduler/internal/app/scheduler_strategy"
+	"scheduler/internal/db/model"
+	"scheduler/internal/logger"
+	"scheduler/internal/observer"
+	"scheduler/internal/repository"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingJobRepository Records the columns of the jobs which are updated
+type recordingJobRepository struct {
+	repository.JobRepository
+	mu      sync.Mutex
+	updated []string
+}
+
+func (r *recordingJobRepository) UpdateJob(_ context.Context, _ model.CronJob, updates map[string]interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for column := range updates {
+		r.updated = append(r.updated, column)
+	}
+	return nil
+}
+
+func TestOnlyTheFiresOfTheScheduleUpdateTheLastAndNextRun(t *testing.T) {
+	logger.Log = zap.NewNop()
+	jobs := &recordingJobRepository{}
+	a := &appScheduler{repo: &repository.Repository{Job: jobs}, dispatcher: observer.NewJobEventDispatcher()}
+	job := model.CronJob{ID: 1003, CronExpression: "0 3 * * *", Timezone: "UTC"}
+	skip := func(context.Context, model.CronJob, model.JobRun) (scheduler_strategy.Query, error) {
+		return scheduler_strategy.Query{Skip: true}, nil
+	}
+
+	for _, trigger := range []string{model.RunTriggerManual, model.RunTriggerResume, model.RunTriggerBackfill} {
+		a.execute(context.Background(), job, newRun(job, time.Now(), trigger), skip)
+	}
+	if len(jobs.updated) > 0 {
+		t.Fatalf("runs outside the schedule updated %v", jobs.updated)
+	}
+	for _, trigger := range []string{model.RunTriggerSchedule, model.RunTriggerCatchUp} {
+		a.execute(context.Background(), job, newRun(job, time.Now(), trigger), skip)
+	}
+	if len(jobs.updated) != 4 {
+		t.Errorf("the fires of the schedule updated %v", jobs.updated)
+	}
+}
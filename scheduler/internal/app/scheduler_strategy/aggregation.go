@@ -0,0 +1,236 @@
+package scheduler_strategy
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"scheduler/internal/sqlbuilder"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// aggregation How an aggregation of a field is computed. SQLite computes the native aggregations. The others are
+// computed in Go over the values of the field, which a query of their own streams group by group
+type aggregation struct {
+	// expr Returns the SQL expression of the aggregation of the field
+	expr func(field sqlbuilder.Expr) sqlbuilder.Expr
+	// post Returns the accumulator which computes the aggregation over the values of a group. It is nil for native
+	// aggregations
+	post func() accumulator
+}
+
+// AggregationNames The aggregations of the strategies in the order they are advertised by the metadata api
+var AggregationNames = []string{"min", "max", "avg", "count", "sum", "count_distinct", "median", "p90", "p95", "p99", "stddev", "variance"}
+
+// NumericAggregations The aggregations which do arithmetic on the values and so need a numeric column
+var NumericAggregations = []string{"avg", "sum", "median", "p90", "p95", "p99", "stddev", "variance"}
+
+var aggregations = map[string]aggregation{
+	"min":   nativeAggregation("min"),
+	"max":   nativeAggregation("max"),
+	"avg":   nativeAggregation("avg"),
+	"count": nativeAggregation("count"),
+	"sum":   nativeAggregation("sum"),
+	"count_distinct": {expr: func(field sqlbuilder.Expr) sqlbuilder.Expr {
+		return sqlbuilder.Func("count", sqlbuilder.Distinct(field))
+	}},
+	"median":   postAggregation(func() accumulator { return &percentileAccumulator{p: 50} }),
+	"p90":      postAggregation(func() accumulator { return &percentileAccumulator{p: 90} }),
+	"p95":      postAggregation(func() accumulator { return &percentileAccumulator{p: 95} }),
+	"p99":      postAggregation(func() accumulator { return &percentileAccumulator{p: 99} }),
+	"stddev":   postAggregation(func() accumulator { return &varianceAccumulator{stddev: true} }),
+	"variance": postAggregation(func() accumulator { return &varianceAccumulator{} }),
+}
+
+func nativeAggregation(function string) aggregation {
+	return aggregation{expr: func(field sqlbuilder.Expr) sqlbuilder.Expr {
+		return sqlbuilder.Func(function, field)
+	}}
+}
+
+// postAggregation Is computed in Go. The query of the measures counts the values of the field in its place, so that
+// it still returns a row per group
+func postAggregation(post func() accumulator) aggregation {
+	return aggregation{
+		expr: func(field sqlbuilder.Expr) sqlbuilder.Expr {
+			return sqlbuilder.Func("count", field)
+		},
+		post: post,
+	}
+}
+
+// aggregationExpr Returns the SQL expression of the aggregation of the field. Unknown aggregations are called as
+// functions, which the query builder rejects unless they are on its allowlist
+func aggregationExpr(name string, field sqlbuilder.Expr) sqlbuilder.Expr {
+	agg, ok := aggregations[name]
+	if !ok {
+		return sqlbuilder.Func(name, field)
+	}
+	return agg.expr(field)
+}
+
+// RowStreamer Runs a query and hands every row to the function as it is read, with the values in the order the query
+// selects them
+type RowStreamer func(text string, args []interface{}, each func(values []interface{}) error) error
+
+// PostAggregate Computes the aggregations which SQLite lacks and sets them on the rows of the result of the query.
+// The values are streamed by the values query, ordered by group, so only the values of one group are held at a time
+func (q Query) PostAggregate(rows []map[string]interface{}, stream RowStreamer) error {
+	post := q.PostAggregation
+	if post == nil {
+		return nil
+	}
+	byGroup := make(map[string]map[string]interface{}, len(rows))
+	for _, row := range rows {
+		keys := make([]interface{}, len(post.GroupKeys))
+		for i, key := range post.GroupKeys {
+			keys[i] = row[key]
+			delete(row, key)
+		}
+		byGroup[groupKey(keys)] = row
+		// groups without values keep no value
+		for _, measure := range post.Measures {
+			row[measure.Alias] = nil
+		}
+	}
+
+	var group string
+	var accumulators []accumulator
+	flush := func() {
+		row, ok := byGroup[group]
+		if !ok || accumulators == nil {
+			return
+		}
+		for i, measure := range post.Measures {
+			row[measure.Alias] = accumulators[i].result()
+		}
+	}
+	err := stream(post.Text, post.Args, func(values []interface{}) error {
+		key := groupKey(values[:len(post.GroupKeys)])
+		if accumulators == nil || key != group {
+			flush()
+			group = key
+			accumulators = make([]accumulator, len(post.Measures))
+			for i, measure := range post.Measures {
+				accumulators[i] = aggregations[measure.Aggregation].post()
+			}
+		}
+		for i, measure := range post.Measures {
+			value, ok, err := numericValue(values[len(post.GroupKeys)+i])
+			if err != nil {
+				return fmt.Errorf("unable to compute %s of %s: %w", measure.Aggregation, measure.Alias, err)
+			}
+			if ok {
+				accumulators[i].add(value)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	flush()
+	return nil
+}
+
+// RowValue Returns the value of a column of a row of a query. The values of the rows scanned into maps are pointers
+func RowValue(value interface{}) interface{} {
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		return v.Elem().Interface()
+	}
+	return value
+}
+
+// groupKey Returns the key which identifies the group of the given values of the group expressions
+func groupKey(values []interface{}) string {
+	var key strings.Builder
+	for _, value := range values {
+		value = RowValue(value)
+		if text, ok := value.([]byte); ok {
+			value = string(text)
+		}
+		fmt.Fprintf(&key, "%T:%v;", value, value)
+	}
+	return key.String()
+}
+
+// numericValue Returns the value of a field as a number. NULL values are left out of the aggregations
+func numericValue(value interface{}) (float64, bool, error) {
+	switch v := RowValue(value).(type) {
+	case nil:
+		return 0, false, nil
+	case int64:
+		return float64(v), true, nil
+	case float64:
+		return v, true, nil
+	case []byte:
+		parsed, err := strconv.ParseFloat(string(v), 64)
+		return parsed, err == nil, err
+	case string:
+		parsed, err := strconv.ParseFloat(v, 64)
+		return parsed, err == nil, err
+	default:
+		return 0, false, fmt.Errorf("value is not a number: %v", v)
+	}
+}
+
+// accumulator Computes an aggregation over the values of a group, one value at a time
+type accumulator interface {
+	add(value float64)
+	// result Returns the aggregation, nil when it is undefined for the values
+	result() interface{}
+}
+
+// percentileAccumulator Computes the p-th percentile, interpolating linearly between the closest ranks. It keeps the
+// values of the group since the ranks are only known once the group is complete
+type percentileAccumulator struct {
+	p      float64
+	values []float64
+}
+
+func (a *percentileAccumulator) add(value float64) {
+	a.values = append(a.values, value)
+}
+
+func (a *percentileAccumulator) result() interface{} {
+	if len(a.values) == 0 {
+		return nil
+	}
+	slices.Sort(a.values)
+	rank := a.p / 100 * float64(len(a.values)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return a.values[lower] + (a.values[upper]-a.values[lower])*(rank-float64(lower))
+}
+
+// varianceAccumulator Computes the sample variance, or the sample standard deviation, with Welford's online
+// algorithm. It is undefined for less than two values
+type varianceAccumulator struct {
+	stddev bool
+	count  int
+	mean   float64
+	// m2 is the sum of the squared differences from the mean
+	m2 float64
+}
+
+func (a *varianceAccumulator) add(value float64) {
+	a.count++
+	delta := value - a.mean
+	a.mean += delta / float64(a.count)
+	a.m2 += delta * (value - a.mean)
+}
+
+func (a *varianceAccumulator) result() interface{} {
+	if a.count < 2 {
+		return nil
+	}
+	variance := a.m2 / float64(a.count-1)
+	if a.stddev {
+		return math.Sqrt(variance)
+	}
+	return variance
+}
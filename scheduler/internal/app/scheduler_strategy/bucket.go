@@ -0,0 +1,100 @@
+package scheduler_strategy
+
+import (
+	"scheduler/internal/db/model"
+	"scheduler/internal/sqlbuilder"
+	"time"
+)
+
+// bucket A grain of a time series. The start of a bucket is computed in Go for the window of the query and in
+// SQL for the rows of the table, both as wall time
+type bucket struct {
+	// start Returns the start of the bucket which contains t
+	start func(t time.Time) time.Time
+	// add Moves the start of a bucket by n buckets
+	add func(start time.Time, n int) time.Time
+	// sqlStart Returns the SQL expression of the start of the bucket of the given column as text
+	sqlStart func(column sqlbuilder.Expr) sqlbuilder.Expr
+	// sqlStep Modifier of the SQLite date functions which moves by one bucket
+	sqlStep string
+}
+
+// bucketNames The buckets in the order they are advertised by the metadata api
+var bucketNames = []string{model.BucketHour, model.BucketDay, model.BucketWeek, model.BucketMonth, model.BucketQuarter, model.BucketYear}
+
+var buckets = map[string]bucket{
+	model.BucketHour: {
+		start: func(t time.Time) time.Time {
+			return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+		},
+		add: func(start time.Time, n int) time.Time { return start.Add(time.Duration(n) * time.Hour) },
+		sqlStart: func(column sqlbuilder.Expr) sqlbuilder.Expr {
+			return sqlbuilder.Func("strftime", sqlbuilder.Value("%Y-%m-%d %H:00:00"), column)
+		},
+		sqlStep: "+1 hour",
+	},
+	model.BucketDay: {
+		start: startOfDay,
+		add:   func(start time.Time, n int) time.Time { return start.AddDate(0, 0, n) },
+		sqlStart: func(column sqlbuilder.Expr) sqlbuilder.Expr {
+			return sqlbuilder.Func("datetime", column, sqlbuilder.Value("start of day"))
+		},
+		sqlStep: "+1 day",
+	},
+	model.BucketWeek: {
+		start: startOfWeek,
+		add:   func(start time.Time, n int) time.Time { return start.AddDate(0, 0, 7*n) },
+		sqlStart: func(column sqlbuilder.Expr) sqlbuilder.Expr {
+			// weekday 0 moves to the next Sunday unless the day is a Sunday already
+			return sqlbuilder.Func("datetime", column, sqlbuilder.Value("start of day"),
+				sqlbuilder.Value("weekday 0"), sqlbuilder.Value("-6 days"))
+		},
+		sqlStep: "+7 days",
+	},
+	model.BucketMonth: {
+		start: func(t time.Time) time.Time {
+			return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+		},
+		add: func(start time.Time, n int) time.Time { return start.AddDate(0, n, 0) },
+		sqlStart: func(column sqlbuilder.Expr) sqlbuilder.Expr {
+			return sqlbuilder.Func("datetime", column, sqlbuilder.Value("start of month"))
+		},
+		sqlStep: "+1 month",
+	},
+	model.BucketQuarter: {
+		start: func(t time.Time) time.Time {
+			return time.Date(t.Year(), (t.Month()-1)/3*3+1, 1, 0, 0, 0, 0, t.Location())
+		},
+		add: func(start time.Time, n int) time.Time { return start.AddDate(0, 3*n, 0) },
+		sqlStart: func(column sqlbuilder.Expr) sqlbuilder.Expr {
+			// moves back by the months since the start of the quarter, e.g. "-2 months" in March
+			monthOfQuarter := sqlbuilder.Binary(
+				sqlbuilder.Binary(sqlbuilder.Func("strftime", sqlbuilder.Value("%m"), column), "-", sqlbuilder.Value(1)),
+				"%", sqlbuilder.Value(3))
+			modifier := sqlbuilder.Binary(sqlbuilder.Binary(sqlbuilder.Value("-"), "||", monthOfQuarter), "||", sqlbuilder.Value(" months"))
+			return sqlbuilder.Func("datetime", column, sqlbuilder.Value("start of month"), modifier)
+		},
+		sqlStep: "+3 months",
+	},
+	model.BucketYear: {
+		start: func(t time.Time) time.Time {
+			return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, t.Location())
+		},
+		add: func(start time.Time, n int) time.Time { return start.AddDate(n, 0, 0) },
+		sqlStart: func(column sqlbuilder.Expr) sqlbuilder.Expr {
+			return sqlbuilder.Func("datetime", column, sqlbuilder.Value("start of year"))
+		},
+		sqlStep: "+1 year",
+	},
+}
+
+// bucketPeriods Splits the days from start to end, both included, into buckets. The first bucket is the one
+// which contains start
+func bucketPeriods(b bucket, start time.Time, end time.Time) []Period {
+	limit := startOfDay(end).AddDate(0, 0, 1)
+	var periods []Period
+	for period := b.start(start); period.Before(limit); period = b.add(period, 1) {
+		periods = append(periods, Period{Start: period, End: b.add(period, 1)})
+	}
+	return periods
+}
@@ -0,0 +1,95 @@
+package scheduler_strategy
+
+import (
+	"fmt"
+	"scheduler/internal/db/model"
+	"scheduler/internal/helper"
+	"scheduler/internal/sqlbuilder"
+	"time"
+)
+
+// MaxLookback The maximum number of buckets a bucketed job covers
+const MaxLookback = 1000
+
+// Parameters of bucketed jobs
+const (
+	ParameterBucket   = "bucket"
+	ParameterLookback = "lookback"
+)
+
+func init() {
+	Register(Definition{
+		Name:         "bucketed",
+		Description:  "A time series of the complete buckets before the bucket of the reference now",
+		Aggregations: AggregationNames,
+		Parameters: []Parameter{
+			{
+				Name:        ParameterBucket,
+				Description: "The grain of the time series",
+				Kind:        ParameterKindString,
+				Required:    true,
+				Options:     bucketNames,
+			},
+			{
+				Name:        ParameterLookback,
+				Description: "The number of complete buckets the time series covers, the whole history when it is left empty",
+				Kind:        ParameterKindInteger,
+				Max:         MaxLookback,
+			},
+		},
+		Output: []string{"bucket_start", "bucket_end"},
+		New: func() JobStrategy {
+			return &BucketedStrategy{}
+		},
+	})
+}
+
+// BucketedStrategy Aggregates the field per time bucket of the job into a time series. Every row is labelled with
+// the start and end of its bucket, the bucket end is excluded
+type BucketedStrategy struct{}
+
+// GenerateQuery Covers the complete buckets before the bucket of the reference now, limited to the lookback of the job
+func (b *BucketedStrategy) GenerateQuery(job model.CronJob, referenceNow time.Time) (Query, error) {
+	bucket, ok := buckets[job.Parameters.String(ParameterBucket)]
+	if !ok {
+		return Query{}, fmt.Errorf("unsupported bucket: %s", job.Parameters.String(ParameterBucket))
+	}
+	end := bucket.start(referenceNow)
+	window := Period{End: end}
+	if lookback := job.Parameters.Int(ParameterLookback); lookback > 0 {
+		window.Start = bucket.add(end, -lookback)
+	}
+	return bucketedQuery(job, bucket, window)
+}
+
+func (b *BucketedStrategy) Periods(job model.CronJob, start time.Time, end time.Time) []Period {
+	bucket, ok := buckets[job.Parameters.String(ParameterBucket)]
+	if !ok {
+		return nil
+	}
+	return bucketPeriods(bucket, start, end)
+}
+
+func (b *BucketedStrategy) GeneratePeriodQuery(job model.CronJob, period Period) (Query, error) {
+	bucket, ok := buckets[job.Parameters.String(ParameterBucket)]
+	if !ok {
+		return Query{}, fmt.Errorf("unsupported bucket: %s", job.Parameters.String(ParameterBucket))
+	}
+	return bucketedQuery(job, bucket, period)
+}
+
+// bucketedQuery Generates the time series of the window. A window without start covers the whole history
+func bucketedQuery(job model.CronJob, bucket bucket, window Period) (Query, error) {
+	column := sqlbuilder.Column(job.DurationFilter)
+	bucketStart := bucket.sqlStart(column)
+	bucketEnd := sqlbuilder.Func("datetime", bucketStart, sqlbuilder.Value(bucket.sqlStep))
+	builder := sqlbuilder.Select(selectMeasures(job, resultAlias, bucketStart.As("bucket_start"), bucketEnd.As("bucket_end"))...).
+		From(job.Table)
+	if !window.Start.IsZero() {
+		builder.Where(sqlbuilder.Compare(column, ">=", sqlbuilder.Value(window.Start.Format(helper.TimeLayout))))
+	}
+	builder.Where(sqlbuilder.Compare(column, "<", sqlbuilder.Value(window.End.Format(helper.TimeLayout)))).
+		GroupBy(bucketStart).
+		OrderBy(bucketStart)
+	return measureQuery(job, resultAlias, builder)
+}
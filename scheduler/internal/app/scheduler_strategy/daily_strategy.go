@@ -1,14 +1,61 @@
 package scheduler_strategy
 
 import (
-	"fmt"
 	"scheduler/internal/db/model"
+	"scheduler/internal/helper"
+	"scheduler/internal/sqlbuilder"
+	"time"
 )
 
+// dailyAlias Alias of the result of jobs without measures
+const dailyAlias = "total_registration"
+
+func init() {
+	Register(Definition{
+		Name:         "daily",
+		Description:  "The whole table per day",
+		Aggregations: AggregationNames,
+		Output:       []string{"registration_date"},
+		Incremental:  true,
+		New: func() JobStrategy {
+			return &DailyStrategy{}
+		},
+	})
+}
+
+// DailyStrategy Aggregates the whole table per day, it does not depend on the reference now
 type DailyStrategy struct{}
 
-func (d *DailyStrategy) GenerateQuery(job model.CronJob) (string, error) {
-	query := fmt.Sprintf("SELECT %s(%s) as total_registration, date(%s) as registration_date FROM %s group by 2",
-		job.Aggregation, job.Field, job.DurationFilter, job.Table)
-	return query, nil
+func (d *DailyStrategy) GenerateQuery(job model.CronJob, _ time.Time) (Query, error) {
+	return dailyQuery(job)
+}
+
+func (d *DailyStrategy) Periods(_ model.CronJob, start time.Time, end time.Time) []Period {
+	return dailyPeriods(start, end)
+}
+
+func (d *DailyStrategy) GeneratePeriodQuery(job model.CronJob, period Period) (Query, error) {
+	day := sqlbuilder.Func("date", sqlbuilder.Column(job.DurationFilter))
+	return dailyQuery(job, sqlbuilder.Compare(day, "=", sqlbuilder.Value(period.Start.Format(helper.DateLayout))))
+}
+
+// ChangedBucketsQuery Lists the days of the rows whose watermark column is after from, up to to included
+func (d *DailyStrategy) ChangedBucketsQuery(job model.CronJob, from interface{}, to interface{}) (Query, error) {
+	return changedBucketsQuery(job, sqlbuilder.Func("date", sqlbuilder.Column(job.DurationFilter)), from, to)
+}
+
+// GenerateBucketsQuery Recomputes the given days only
+func (d *DailyStrategy) GenerateBucketsQuery(job model.CronJob, buckets []interface{}) (Query, error) {
+	day := sqlbuilder.Func("date", sqlbuilder.Column(job.DurationFilter))
+	return dailyQuery(job, sqlbuilder.In(day, bucketValues(buckets)...))
+}
+
+// dailyQuery Aggregates the rows which match the conditions per day
+func dailyQuery(job model.CronJob, conditions ...sqlbuilder.Expr) (Query, error) {
+	day := sqlbuilder.Func("date", sqlbuilder.Column(job.DurationFilter))
+	builder := sqlbuilder.Select(selectMeasures(job, dailyAlias, day.As("registration_date"))...).
+		From(job.Table).
+		Where(conditions...).
+		GroupBy(day)
+	return measureQuery(job, dailyAlias, builder)
 }
@@ -0,0 +1,58 @@
+package scheduler_strategy
+
+import (
+	"fmt"
+	"scheduler/internal/db/model"
+	"scheduler/internal/sqlbuilder"
+)
+
+// comparisonOperators SQL operator of the filter operators which compare the field with a single value
+var comparisonOperators = map[string]string{
+	model.FilterOperatorEq:  "=",
+	model.FilterOperatorNe:  "<>",
+	model.FilterOperatorGt:  ">",
+	model.FilterOperatorGte: ">=",
+	model.FilterOperatorLt:  "<",
+	model.FilterOperatorLte: "<=",
+}
+
+// filterConditions Compiles the filters of the job into conditions. The values of the filters are bound as parameters
+func filterConditions(job model.CronJob) ([]sqlbuilder.Expr, error) {
+	var conditions []sqlbuilder.Expr
+	for _, filter := range job.Filters {
+		condition, err := filterCondition(filter)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+	return conditions, nil
+}
+
+// filterCondition Compiles a filter into a condition
+func filterCondition(filter model.Filter) (sqlbuilder.Expr, error) {
+	field := sqlbuilder.Column(filter.Field)
+	if operator, ok := comparisonOperators[filter.Operator]; ok {
+		return sqlbuilder.Compare(field, operator, sqlbuilder.Value(filter.Value)), nil
+	}
+	values, _ := filter.Value.([]interface{})
+	switch filter.Operator {
+	case model.FilterOperatorBetween:
+		if len(values) != 2 {
+			return sqlbuilder.Expr{}, fmt.Errorf("between filter on %s needs two values", filter.Field)
+		}
+		return sqlbuilder.Between(field, sqlbuilder.Value(values[0]), sqlbuilder.Value(values[1])), nil
+	case model.FilterOperatorIn:
+		var list []sqlbuilder.Expr
+		for _, value := range values {
+			list = append(list, sqlbuilder.Value(value))
+		}
+		return sqlbuilder.In(field, list...), nil
+	case model.FilterOperatorIsNull:
+		return sqlbuilder.IsNull(field), nil
+	case model.FilterOperatorNotNull:
+		return sqlbuilder.IsNotNull(field), nil
+	default:
+		return sqlbuilder.Expr{}, fmt.Errorf("unsupported filter operator: %s", filter.Operator)
+	}
+}
@@ -1,22 +1,74 @@
 package scheduler_strategy
 
 import (
-	"fmt"
 	"scheduler/internal/db/model"
 	"scheduler/internal/helper"
+	"scheduler/internal/sqlbuilder"
+	"time"
 )
 
-type GenericDurationStrategy struct{}
+// resultAlias Alias of the result of jobs without measures
+const resultAlias = "result"
 
-func (g *GenericDurationStrategy) GenerateQuery(job model.CronJob) (string, error) {
-	durationClause := helper.GenerateDurationClauses(job.DurationFilter)
-	whereClause, ok := durationClause[job.Duration]
-	if !ok {
-		return "", fmt.Errorf("unsupported duration: %s", job.Duration)
-	}
+func init() {
+	registerDayDuration("today", "The day of the reference now", "+0 day", func(today time.Time) Period {
+		return Period{Start: today, End: today.AddDate(0, 0, 1)}
+	})
+	registerDayDuration("yesterday", "The day before the reference now", "-1 day", func(today time.Time) Period {
+		return Period{Start: today.AddDate(0, 0, -1), End: today}
+	})
+	registerDayDuration("last_7_days", "The day a week before the reference now. Sql jobs cover the 7 days before the day of the reference now",
+		"-7 day", func(today time.Time) Period {
+			return Period{Start: today.AddDate(0, 0, -7), End: today}
+		})
+	registerDayDuration("last_30_days", "The day a month before the reference now. Sql jobs cover the month before the day of the reference now",
+		"-1 month", func(today time.Time) Period {
+			return Period{Start: today.AddDate(0, -1, 0), End: today}
+		})
+}
+
+// registerDayDuration Registers a duration which covers the single day the modifier moves the reference now to. The
+// window of sql jobs is computed from the start of the day of the reference now
+func registerDayDuration(name string, description string, modifier string, sqlPeriod func(today time.Time) Period) {
+	Register(Definition{
+		Name:         name,
+		Description:  description,
+		Aggregations: AggregationNames,
+		Output:       []string{"timestamp"},
+		New: func() JobStrategy {
+			return &GenericDurationStrategy{modifier: modifier}
+		},
+		SqlPeriod: func(referenceNow time.Time) Period {
+			return sqlPeriod(startOfDay(referenceNow))
+		},
+	})
+}
+
+// GenericDurationStrategy Aggregates the single day the modifier of its duration moves the reference now to
+type GenericDurationStrategy struct {
+	// modifier Modifier of the SQLite date function, e.g. "-1 day"
+	modifier string
+}
 
-	query := fmt.Sprintf("SELECT %s(%s) AS result, timestamp FROM %s WHERE %s",
-		job.Aggregation, job.Field, job.Table, whereClause)
+func (g *GenericDurationStrategy) GenerateQuery(job model.CronJob, referenceNow time.Time) (Query, error) {
+	day := sqlbuilder.Func("date", sqlbuilder.Value(referenceNow.Format(helper.TimeLayout)), sqlbuilder.Value(g.modifier))
+	return dayQuery(job, day)
+}
+
+// Periods Every duration covers a single day, so the periods are days
+func (g *GenericDurationStrategy) Periods(_ model.CronJob, start time.Time, end time.Time) []Period {
+	return dailyPeriods(start, end)
+}
+
+func (g *GenericDurationStrategy) GeneratePeriodQuery(job model.CronJob, period Period) (Query, error) {
+	return dayQuery(job, sqlbuilder.Value(period.Start.Format(helper.DateLayout)))
+}
 
-	return query, nil
+// dayQuery Aggregates the rows of the given day
+func dayQuery(job model.CronJob, day sqlbuilder.Expr) (Query, error) {
+	column := sqlbuilder.Column(job.DurationFilter)
+	builder := sqlbuilder.Select(selectMeasures(job, resultAlias, column.As("timestamp"))...).
+		From(job.Table).
+		Where(sqlbuilder.Compare(sqlbuilder.Func("date", column), "=", day))
+	return measureQuery(job, resultAlias, builder)
 }
@@ -0,0 +1,51 @@
+package scheduler_strategy
+
+import (
+	"scheduler/internal/db/model"
+	"scheduler/internal/sqlbuilder"
+)
+
+// Columns of the queries of the incremental runs
+const (
+	WatermarkAlias = "watermark"
+	BucketAlias    = "bucket"
+)
+
+// IncrementalStrategy Implemented by the strategies whose buckets can be recomputed on their own. Incremental runs
+// only recompute the buckets of the rows after the watermark of the job
+type IncrementalStrategy interface {
+	// ChangedBucketsQuery Generates the query which lists the buckets of the rows whose watermark column is after
+	// from, up to to included. Its rows have the single column BucketAlias
+	ChangedBucketsQuery(job model.CronJob, from interface{}, to interface{}) (Query, error)
+	// GenerateBucketsQuery Generates the query of the job which only covers the given buckets
+	GenerateBucketsQuery(job model.CronJob, buckets []interface{}) (Query, error)
+}
+
+// WatermarkQuery Generates the query of the highest value of the watermark column among the rows the job aggregates.
+// Its single row has the column WatermarkAlias, which is NULL when there are no rows
+func WatermarkQuery(job model.CronJob) (Query, error) {
+	watermark := sqlbuilder.Func("max", sqlbuilder.Column(job.WatermarkColumn)).As(WatermarkAlias)
+	return filteredQuery(job, sqlbuilder.Select(watermark).From(job.Table))
+}
+
+// changedBucketsQuery Generates the query of the distinct buckets of the rows whose watermark column is after from,
+// up to to included
+func changedBucketsQuery(job model.CronJob, bucket sqlbuilder.Expr, from interface{}, to interface{}) (Query, error) {
+	watermarkColumn := sqlbuilder.Column(job.WatermarkColumn)
+	builder := sqlbuilder.Select(sqlbuilder.Distinct(bucket).As(BucketAlias)).
+		From(job.Table).
+		Where(
+			sqlbuilder.Compare(watermarkColumn, ">", sqlbuilder.Value(from)),
+			sqlbuilder.Compare(watermarkColumn, "<=", sqlbuilder.Value(to)),
+		)
+	return filteredQuery(job, builder)
+}
+
+// bucketValues Returns the buckets as values of an IN condition
+func bucketValues(buckets []interface{}) []sqlbuilder.Expr {
+	values := make([]sqlbuilder.Expr, 0, len(buckets))
+	for _, bucket := range buckets {
+		values = append(values, sqlbuilder.Value(bucket))
+	}
+	return values
+}
@@ -0,0 +1,81 @@
+package scheduler_strategy
+
+import (
+	"fmt"
+	"scheduler/internal/db/model"
+	"scheduler/internal/sqlbuilder"
+	"slices"
+)
+
+// groupKeyPrefix Prefix of the aliases of the keys of the groups of the queries of measures which are computed after
+// the query
+const groupKeyPrefix = "group_key_"
+
+// jobMeasures Returns the measures of the job. Jobs without measures compute the aggregation of their field under
+// the default alias of the strategy
+func jobMeasures(job model.CronJob, defaultAlias string) []model.Measure {
+	if len(job.Measures) > 0 {
+		return job.Measures
+	}
+	return []model.Measure{{Field: job.Field, Aggregation: job.Aggregation, Alias: defaultAlias}}
+}
+
+// selectMeasures Returns the select list which computes every measure under its alias, followed by the columns
+// of the strategy
+func selectMeasures(job model.CronJob, defaultAlias string, columns ...sqlbuilder.Expr) []sqlbuilder.Expr {
+	var measures []sqlbuilder.Expr
+	for _, measure := range jobMeasures(job, defaultAlias) {
+		measures = append(measures, aggregationExpr(measure.Aggregation, sqlbuilder.Column(measure.Field)).As(measure.Alias))
+	}
+	return append(measures, columns...)
+}
+
+// measureQuery Adds the filters of the job to the query of its measures and builds it. The measures which are
+// computed after the query get the values query which streams the values of their fields
+func measureQuery(job model.CronJob, defaultAlias string, builder *sqlbuilder.SelectQuery) (Query, error) {
+	var post []model.Measure
+	for _, measure := range jobMeasures(job, defaultAlias) {
+		if aggregations[measure.Aggregation].post != nil {
+			post = append(post, measure)
+		}
+	}
+	if len(post) == 0 {
+		return filteredQuery(job, builder)
+	}
+
+	// both queries select the keys of the groups, so the values can be matched with the rows of their group
+	var groupKeys []string
+	var keyColumns []sqlbuilder.Expr
+	for i, group := range builder.Groups() {
+		alias := fmt.Sprintf("%s%d", groupKeyPrefix, i)
+		groupKeys = append(groupKeys, alias)
+		keyColumns = append(keyColumns, group.As(alias))
+	}
+	query, err := filteredQuery(job, builder.Columns(keyColumns...))
+	if err != nil {
+		return Query{}, err
+	}
+	valueColumns := slices.Clone(keyColumns)
+	for _, measure := range post {
+		valueColumns = append(valueColumns, sqlbuilder.Column(measure.Field).As(measure.Alias))
+	}
+	text, args, err := builder.Ungrouped(valueColumns...).Build()
+	if err != nil {
+		return Query{}, err
+	}
+	query.PostAggregation = &PostAggregation{Text: text, Args: args, GroupKeys: groupKeys, Measures: post}
+	return query, nil
+}
+
+// filteredQuery Adds the filters of the job to the query and builds it
+func filteredQuery(job model.CronJob, builder *sqlbuilder.SelectQuery) (Query, error) {
+	filters, err := filterConditions(job)
+	if err != nil {
+		return Query{}, err
+	}
+	text, args, err := builder.Where(filters...).Build()
+	if err != nil {
+		return Query{}, err
+	}
+	return Query{Text: text, Args: args}, nil
+}
@@ -0,0 +1,40 @@
+package scheduler_strategy
+
+import (
+	"scheduler/internal/db/model"
+	"time"
+)
+
+// Period A window of the history which a backfill posts one result for. Start is included and End is excluded
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+// PeriodStrategy Implemented by the strategies which can be backfilled
+type PeriodStrategy interface {
+	// Periods Splits the days from start to end, both included, into the periods the job posts one result for
+	Periods(job model.CronJob, start time.Time, end time.Time) []Period
+	// GeneratePeriodQuery Generates the query of the job which only covers the given period
+	GeneratePeriodQuery(job model.CronJob, period Period) (Query, error)
+}
+
+// dailyPeriods Splits the range into days
+func dailyPeriods(start time.Time, end time.Time) []Period {
+	return bucketPeriods(buckets[model.BucketDay], start, end)
+}
+
+// weeklyPeriods Splits the range into ISO weeks, from the Monday of the week of start to the week of end
+func weeklyPeriods(start time.Time, end time.Time) []Period {
+	return bucketPeriods(buckets[model.BucketWeek], start, end)
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// startOfWeek Returns the start of the ISO week of t, which is a Monday
+func startOfWeek(t time.Time) time.Time {
+	day := startOfDay(t)
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
@@ -1,19 +1,56 @@
 package scheduler_strategy
 
 import (
-	"fmt"
 	"scheduler/internal/db/model"
+	"scheduler/internal/helper"
+	"scheduler/internal/sqlbuilder"
+	"time"
 )
 
+func init() {
+	Register(Definition{
+		Name:         "recent_week",
+		Description:  "The last full ISO week before the week of the reference now",
+		Aggregations: AggregationNames,
+		Output:       []string{"week_number"},
+		New: func() JobStrategy {
+			return &RecentWeekStrategy{}
+		},
+		SqlPeriod: lastWeek,
+	})
+}
+
+// RecentWeekStrategy Aggregates the last full ISO week before the week of the reference now
 type RecentWeekStrategy struct{}
 
-func (r *RecentWeekStrategy) GenerateQuery(job model.CronJob) (string, error) {
-	query := fmt.Sprintf(`
-WITH week_average AS (
-	SELECT %[3]s(%[4]s) AS result, strftime('%%W', %[1]s) as week_number, strftime("%%Y", %[1]s) as year
-	FROM %[2]s group by 2,3
-)
-select result, week_number from week_average where week_number='50' and year='2022'`,
-		job.DurationFilter, job.Table, job.Aggregation, job.Field)
-	return query, nil
+func (r *RecentWeekStrategy) GenerateQuery(job model.CronJob, referenceNow time.Time) (Query, error) {
+	return weekQuery(job, lastWeek(referenceNow))
+}
+
+func (r *RecentWeekStrategy) Periods(_ model.CronJob, start time.Time, end time.Time) []Period {
+	return weeklyPeriods(start, end)
+}
+
+func (r *RecentWeekStrategy) GeneratePeriodQuery(job model.CronJob, period Period) (Query, error) {
+	return weekQuery(job, period)
+}
+
+// lastWeek Returns the ISO week before the week of the reference now
+func lastWeek(referenceNow time.Time) Period {
+	monday := startOfWeek(referenceNow)
+	return Period{Start: monday.AddDate(0, 0, -7), End: monday}
+}
+
+// weekQuery Aggregates the rows of the days of the week, which is labelled with its ISO week number. The days are
+// matched by range so a week which spans the turn of the year is not split
+func weekQuery(job model.CronJob, week Period) (Query, error) {
+	day := sqlbuilder.Func("date", sqlbuilder.Column(job.DurationFilter))
+	_, weekNumber := week.Start.ISOWeek()
+	builder := sqlbuilder.Select(selectMeasures(job, resultAlias, sqlbuilder.Value(weekNumber).As("week_number"))...).
+		From(job.Table).
+		Where(
+			sqlbuilder.Compare(day, ">=", sqlbuilder.Value(week.Start.Format(helper.DateLayout))),
+			sqlbuilder.Compare(day, "<", sqlbuilder.Value(week.End.Format(helper.DateLayout))),
+		)
+	return measureQuery(job, resultAlias, builder)
 }
@@ -0,0 +1,164 @@
+package scheduler_strategy
+
+import (
+	"fmt"
+	"math"
+	"scheduler/internal/db/model"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of the parameters of a strategy
+const (
+	ParameterKindString  = "string"
+	ParameterKindInteger = "integer"
+)
+
+// Parameter A value of the job which a strategy reads besides the table, the measures and the duration filter. The
+// values are validated and stored generically, so a strategy declares its parameters in its own file only. String
+// parameters are limited to their options when there are any, integer parameters to Min and Max
+type Parameter struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Kind        string   `json:"kind"`
+	Required    bool     `json:"required"`
+	Options     []string `json:"options,omitempty"`
+	Min         int      `json:"min,omitempty"`
+	Max         int      `json:"max,omitempty"`
+}
+
+// Definition Describes a duration of the jobs and the strategy which generates its query
+type Definition struct {
+	// Name is the duration option of the jobs which use the strategy
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Aggregations are the aggregations the strategy can compute
+	Aggregations []string    `json:"aggregations"`
+	Parameters   []Parameter `json:"parameters,omitempty"`
+	// Output are the columns of every posted row next to the measures
+	Output []string `json:"output"`
+	// Incremental is set when jobs with the duration can keep a watermark. Their strategy implements
+	// IncrementalStrategy
+	Incremental bool `json:"incremental"`
+	// New Returns the strategy which generates the query of the jobs with the duration
+	New func() JobStrategy `json:"-"`
+	// SqlPeriod Returns the window which is bound to the parameters of sql jobs with the duration relative to the
+	// reference now. Durations without it can not be used by sql jobs
+	SqlPeriod func(referenceNow time.Time) Period `json:"-"`
+}
+
+var registryMutex sync.RWMutex
+var registry = make(map[string]Definition)
+
+// Register Adds the strategy of a duration. Strategies register themselves from the init function of their file
+func Register(definition Definition) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	if definition.Name == "" || definition.New == nil {
+		panic("a strategy needs a name and a constructor")
+	}
+	if _, ok := definition.New().(IncrementalStrategy); definition.Incremental && !ok {
+		panic(fmt.Sprintf("incremental strategy does not implement IncrementalStrategy: %s", definition.Name))
+	}
+	if _, ok := registry[definition.Name]; ok {
+		panic(fmt.Sprintf("strategy is already registered: %s", definition.Name))
+	}
+	registry[definition.Name] = definition
+}
+
+// Lookup Returns the definition of the strategy of a duration
+func Lookup(name string) (Definition, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	definition, ok := registry[name]
+	return definition, ok
+}
+
+// Definitions Returns the definitions of all the registered strategies, ordered by name
+func Definitions() []Definition {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	definitions := make([]Definition, 0, len(registry))
+	for _, definition := range registry {
+		definitions = append(definitions, definition)
+	}
+	slices.SortFunc(definitions, func(a, b Definition) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return definitions
+}
+
+// DurationNames Returns the names of the registered durations, ordered by name
+func DurationNames() []string {
+	var names []string
+	for _, definition := range Definitions() {
+		names = append(names, definition.Name)
+	}
+	return names
+}
+
+// SqlDurationNames Returns the names of the durations which sql jobs can use, ordered by name
+func SqlDurationNames() []string {
+	var names []string
+	for _, definition := range Definitions() {
+		if definition.SqlPeriod != nil {
+			names = append(names, definition.Name)
+		}
+	}
+	return names
+}
+
+// Parameter Returns the parameter of the strategy with the given name
+func (d Definition) Parameter(name string) (Parameter, bool) {
+	for _, parameter := range d.Parameters {
+		if parameter.Name == name {
+			return parameter, true
+		}
+	}
+	return Parameter{}, false
+}
+
+// Valid Checks a value of the parameter. Values which are left out or empty are only valid for optional parameters.
+// Integers are the whole numbers of the decoded JSON
+func (p Parameter) Valid(value interface{}) bool {
+	if value == nil {
+		return !p.Required
+	}
+	switch p.Kind {
+	case ParameterKindString:
+		s, ok := value.(string)
+		if !ok {
+			return false
+		}
+		if s == "" {
+			return !p.Required
+		}
+		return len(p.Options) == 0 || slices.Contains(p.Options, s)
+	case ParameterKindInteger:
+		n, ok := value.(float64)
+		if !ok || n != math.Trunc(n) || (n == 0 && p.Required) {
+			return false
+		}
+		return n >= float64(p.Min) && n <= float64(p.Max)
+	default:
+		return false
+	}
+}
+
+// ParameterValues Returns the values of the parameters the strategy declares, leaving out the values which are not set
+func (d Definition) ParameterValues(values map[string]interface{}) model.ParameterMap {
+	var parameters model.ParameterMap
+	for _, parameter := range d.Parameters {
+		value, ok := values[parameter.Name]
+		if !ok || value == nil || value == "" {
+			continue
+		}
+		if parameters == nil {
+			parameters = make(model.ParameterMap)
+		}
+		parameters[parameter.Name] = value
+	}
+	return parameters
+}
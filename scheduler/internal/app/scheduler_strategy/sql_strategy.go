@@ -0,0 +1,49 @@
+package scheduler_strategy
+
+import (
+	"database/sql"
+	"scheduler/internal/db/model"
+	"scheduler/internal/helper"
+	"time"
+)
+
+// Named parameters which can be used in the query of a sql job. They are bound as text in helper.TimeLayout
+const (
+	ParamPeriodStart = "period_start"
+	ParamPeriodEnd   = "period_end"
+	ParamNow         = "now"
+)
+
+// SqlStrategy Runs the query of a sql job. The period parameters are bound to the window of the duration of the job
+// which ends at the day of the reference now, the period start is included and the period end is excluded
+type SqlStrategy struct {
+	definition Definition
+}
+
+func (s *SqlStrategy) GenerateQuery(job model.CronJob, referenceNow time.Time) (Query, error) {
+	return sqlQuery(job, s.definition.SqlPeriod(referenceNow), referenceNow), nil
+}
+
+// Periods A sql job is backfilled with the periods of the strategy of its duration, daily when it has none
+func (s *SqlStrategy) Periods(job model.CronJob, start time.Time, end time.Time) []Period {
+	if periodStrategy, ok := s.definition.New().(PeriodStrategy); ok {
+		return periodStrategy.Periods(job, start, end)
+	}
+	return dailyPeriods(start, end)
+}
+
+// GeneratePeriodQuery Binds the parameters to the period. The end of the period is used as now
+func (s *SqlStrategy) GeneratePeriodQuery(job model.CronJob, period Period) (Query, error) {
+	return sqlQuery(job, period, period.End), nil
+}
+
+func sqlQuery(job model.CronJob, period Period, now time.Time) Query {
+	return Query{
+		Text: job.Query,
+		Args: []interface{}{
+			sql.Named(ParamPeriodStart, period.Start.Format(helper.TimeLayout)),
+			sql.Named(ParamPeriodEnd, period.End.Format(helper.TimeLayout)),
+			sql.Named(ParamNow, now.Format(helper.TimeLayout)),
+		},
+	}
+}
@@ -3,21 +3,49 @@ package scheduler_strategy
 import (
 	"fmt"
 	"scheduler/internal/db/model"
+	"time"
 )
 
+// Query A generated query together with the values of its named parameters. PostAggregation is set when measures
+// are computed after the query
+type Query struct {
+	Text            string
+	Args            []interface{}
+	PostAggregation *PostAggregation
+	// Skip is set when an incremental run has nothing to recompute. The query is not run then
+	Skip bool
+	// Watermark is the watermark of the job once the result is delivered. It is only set for incremental runs
+	Watermark *model.Watermark
+}
+
+// PostAggregation The values query of the measures which are computed after the query. It selects the keys of the
+// group of every row the query aggregates, followed by the values of the fields of the measures
+type PostAggregation struct {
+	Text string
+	Args []interface{}
+	// GroupKeys are the aliases under which both queries select the keys of the groups. They are removed from the result
+	GroupKeys []string
+	// Measures are in the order the values query selects their fields
+	Measures []model.Measure
+}
+
+// JobStrategy Generates the query of a job. The time window of the query is relative to the reference now of the run
 type JobStrategy interface {
-	GenerateQuery(job model.CronJob) (string, error)
+	GenerateQuery(job model.CronJob, referenceNow time.Time) (Query, error)
 }
 
-func GetStrategy(duration string) (JobStrategy, error) {
-	switch duration {
-	case "daily":
-		return &DailyStrategy{}, nil
-	case "recent_week":
-		return &RecentWeekStrategy{}, nil
-	case "today", "yesterday", "last_7_days", "last_30_days":
-		return &GenericDurationStrategy{}, nil
-	default:
-		return nil, fmt.Errorf("no strategy for the duration: %s", duration)
+// GetStrategy Returns the strategy of the job. Sql jobs run their own query, the other jobs are generated by the
+// strategy registered for their duration
+func GetStrategy(job model.CronJob) (JobStrategy, error) {
+	definition, ok := Lookup(job.Duration)
+	if !ok {
+		return nil, fmt.Errorf("no strategy for the duration: %s", job.Duration)
+	}
+	if job.Type == model.JobTypeSql {
+		if definition.SqlPeriod == nil {
+			return nil, fmt.Errorf("unsupported duration for a sql job: %s", job.Duration)
+		}
+		return &SqlStrategy{definition: definition}, nil
 	}
+	return definition.New(), nil
 }
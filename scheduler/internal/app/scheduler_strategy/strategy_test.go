@@ -0,0 +1,204 @@
+package scheduler_strategy
+
+import (
+	"scheduler/internal/db/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+const injection = `timestamp) = 1; DROP TABLE registration; --`
+
+var referenceNow = time.Date(2022, 12, 22, 12, 0, 0, 0, time.UTC)
+
+func validJob(duration string) model.CronJob {
+	return model.CronJob{
+		Type:           model.JobTypeAggregation,
+		Table:          "registration",
+		Field:          "weight",
+		Aggregation:    "avg",
+		DurationFilter: "timestamp",
+		Duration:       duration,
+		Parameters:     model.ParameterMap{ParameterBucket: model.BucketDay, ParameterLookback: 7.0},
+		Filters:        model.FilterList{{Field: "weight", Operator: model.FilterOperatorGt, Value: 0.0}},
+	}
+}
+
+// generateQueries Generates the scheduled query and the period query of the job
+func generateQueries(t *testing.T, job model.CronJob) map[string]error {
+	strategy, err := GetStrategy(job)
+	if err != nil {
+		t.Fatalf("no strategy for %s: %v", job.Duration, err)
+	}
+	_, scheduledErr := strategy.GenerateQuery(job, referenceNow)
+	errs := map[string]error{"scheduled": scheduledErr}
+	if periodStrategy, ok := strategy.(PeriodStrategy); ok {
+		_, errs["period"] = periodStrategy.GeneratePeriodQuery(job, Period{Start: referenceNow.AddDate(0, 0, -7), End: referenceNow})
+	}
+	return errs
+}
+
+func TestStrategiesBindValuesAndQuoteIdentifiers(t *testing.T) {
+	for _, duration := range DurationNames() {
+		job := validJob(duration)
+		strategy, err := GetStrategy(job)
+		if err != nil {
+			t.Fatalf("no strategy for %s: %v", duration, err)
+		}
+		query, err := strategy.GenerateQuery(job, referenceNow)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", duration, err)
+		}
+		if strings.Contains(query.Text, "'") {
+			t.Errorf("%s: query contains a literal: %s", duration, query.Text)
+		}
+		if !strings.Contains(query.Text, `FROM "registration"`) || !strings.Contains(query.Text, `"weight" > ?`) {
+			t.Errorf("%s: identifiers are not quoted or the filter is not bound: %s", duration, query.Text)
+		}
+	}
+}
+
+func TestStrategiesRejectMaliciousJobs(t *testing.T) {
+	malicious := map[string]func(job *model.CronJob){
+		"table":           func(job *model.CronJob) { job.Table = injection },
+		"field":           func(job *model.CronJob) { job.Field = injection },
+		"duration filter": func(job *model.CronJob) { job.DurationFilter = injection },
+		"aggregation":     func(job *model.CronJob) { job.Aggregation = "load_extension" },
+		"measure alias": func(job *model.CronJob) {
+			job.Measures = model.MeasureList{{Field: "weight", Aggregation: "avg", Alias: injection}}
+		},
+		"filter field": func(job *model.CronJob) {
+			job.Filters = model.FilterList{{Field: injection, Operator: model.FilterOperatorIsNull}}
+		},
+		"filter operator": func(job *model.CronJob) {
+			job.Filters = model.FilterList{{Field: "weight", Operator: "= 1 OR 1 =", Value: 1.0}}
+		},
+	}
+	for _, duration := range DurationNames() {
+		for name, modify := range malicious {
+			job := validJob(duration)
+			modify(&job)
+			for kind, err := range generateQueries(t, job) {
+				if err == nil {
+					t.Errorf("%s: %s query of a job with a malicious %s was generated", duration, kind, name)
+				}
+			}
+		}
+	}
+}
+
+func TestPostAggregationsAreComputedOverTheStreamedValuesOfEveryGroup(t *testing.T) {
+	job := validJob("daily")
+	job.Measures = model.MeasureList{
+		{Field: "weight", Aggregation: "avg", Alias: "mean"},
+		{Field: "weight", Aggregation: "median", Alias: "median"},
+		{Field: "weight", Aggregation: "stddev", Alias: "spread"},
+	}
+	query, err := dailyQuery(job)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	post := query.PostAggregation
+	if post == nil || len(post.GroupKeys) != 1 || len(post.Measures) != 2 {
+		t.Fatalf("unexpected post aggregation: %+v", post)
+	}
+	if strings.Contains(query.Text, "group_concat") || strings.Contains(post.Text, "GROUP BY") {
+		t.Errorf("values are not streamed:\n%s\n%s", query.Text, post.Text)
+	}
+
+	rows := []map[string]interface{}{
+		{"registration_date": "2022-12-20", post.GroupKeys[0]: "2022-12-20", "mean": 2.0, "median": int64(3), "spread": int64(3)},
+		{"registration_date": "2022-12-21", post.GroupKeys[0]: "2022-12-21", "mean": 5.0, "median": int64(1), "spread": int64(1)},
+		{"registration_date": "2022-12-22", post.GroupKeys[0]: "2022-12-22", "mean": nil, "median": int64(0), "spread": int64(0)},
+	}
+	streamed := [][]interface{}{
+		{"2022-12-20", 1.0, 1.0},
+		{"2022-12-20", int64(2), int64(2)},
+		{"2022-12-20", "3", "3"},
+		{"2022-12-21", 5.0, 5.0},
+		{"2022-12-22", nil, nil},
+	}
+	err = query.PostAggregate(rows, func(text string, args []interface{}, each func([]interface{}) error) error {
+		if text != post.Text {
+			t.Errorf("streamed %s", text)
+		}
+		for _, values := range streamed {
+			if err := each(values); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []map[string]interface{}{
+		{"registration_date": "2022-12-20", "mean": 2.0, "median": 2.0, "spread": 1.0},
+		{"registration_date": "2022-12-21", "mean": 5.0, "median": 5.0, "spread": nil},
+		{"registration_date": "2022-12-22", "mean": nil, "median": nil, "spread": nil},
+	}
+	for i, row := range rows {
+		if len(row) != len(expected[i]) {
+			t.Errorf("row %d has the columns %v", i, row)
+		}
+		for column, value := range expected[i] {
+			if row[column] != value {
+				t.Errorf("row %d: %s is %v, expected %v", i, column, row[column], value)
+			}
+		}
+	}
+}
+
+func TestRecentWeekCoversTheIsoWeekAcrossTheTurnOfTheYear(t *testing.T) {
+	// the week before Wednesday 6 January 2021 is ISO week 53, from Monday 28 December 2020 to Sunday 3 January 2021
+	query, err := (&RecentWeekStrategy{}).GenerateQuery(validJob("recent_week"), time.Date(2021, 1, 6, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(query.Text, "strftime") {
+		t.Errorf("weeks are matched by week number: %s", query.Text)
+	}
+	expected := []interface{}{53, "2020-12-28", "2021-01-04"}
+	if len(query.Args) < len(expected) {
+		t.Fatalf("unexpected args: %v", query.Args)
+	}
+	for i, arg := range expected {
+		if query.Args[i] != arg {
+			t.Errorf("arg %d is %v, expected %v", i, query.Args[i], arg)
+		}
+	}
+}
+
+func TestParametersAreValidatedAndKeptFromTheDefinition(t *testing.T) {
+	definition, ok := Lookup("bucketed")
+	if !ok {
+		t.Fatal("bucketed is not registered")
+	}
+	bucket, _ := definition.Parameter(ParameterBucket)
+	lookback, _ := definition.Parameter(ParameterLookback)
+	cases := []struct {
+		parameter Parameter
+		value     interface{}
+		valid     bool
+	}{
+		{bucket, model.BucketWeek, true},
+		{bucket, nil, false},
+		{bucket, "fortnight", false},
+		{bucket, 7.0, false},
+		{lookback, nil, true},
+		{lookback, 4.0, true},
+		{lookback, 2.5, false},
+		{lookback, float64(MaxLookback + 1), false},
+		{lookback, "4", false},
+	}
+	for _, c := range cases {
+		if c.parameter.Valid(c.value) != c.valid {
+			t.Errorf("%s: %v is valid: %v", c.parameter.Name, c.value, !c.valid)
+		}
+	}
+
+	values := definition.ParameterValues(map[string]interface{}{ParameterBucket: model.BucketWeek, ParameterLookback: nil, "other": 1.0})
+	if len(values) != 1 || values.String(ParameterBucket) != model.BucketWeek {
+		t.Errorf("kept %v", values)
+	}
+}
@@ -0,0 +1,45 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock Decides the "now" the time window of a run is computed from
+type Clock interface {
+	// ReferenceNow Returns the reference now of a run which fired at the given time
+	ReferenceNow(firedAt time.Time) time.Time
+}
+
+// RealClock Computes the windows relative to the time the run fired at
+type RealClock struct{}
+
+func (RealClock) ReferenceNow(firedAt time.Time) time.Time {
+	return firedAt
+}
+
+// FixedClock Computes the windows of every run relative to the same configured time. It is used to run the jobs
+// against a fixed snapshot of data
+type FixedClock struct {
+	Now time.Time
+}
+
+func (f FixedClock) ReferenceNow(time.Time) time.Time {
+	return f.Now
+}
+
+var m sync.Mutex
+var clock Clock = RealClock{}
+
+// InitClock Setting the clock used by the schedulers of the process
+func InitClock(c Clock) {
+	m.Lock()
+	defer m.Unlock()
+	clock = c
+}
+
+func GetClock() Clock {
+	m.Lock()
+	defer m.Unlock()
+	return clock
+}
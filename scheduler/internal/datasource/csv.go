@@ -0,0 +1,115 @@
+package datasource
+
+import (
+	"encoding/csv"
+	"fmt"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"os"
+	"path/filepath"
+	"scheduler/internal/logger"
+	"scheduler/internal/sqlbuilder"
+	"strconv"
+	"strings"
+)
+
+// Column types of the tables loaded from csv files
+const (
+	csvTypeInteger = "INTEGER"
+	csvTypeReal    = "REAL"
+	csvTypeText    = "TEXT"
+)
+
+// loadCsvDirectory Loads every csv file of the directory into a table named after the file. The first line of a
+// file holds the names of the columns. Files whose table or column names are not plain identifiers are skipped
+func loadCsvDirectory(db *gorm.DB, dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.csv"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		table := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		err = loadCsvFile(db, table, file)
+		if err != nil {
+			logger.Log.Warn("Skipping csv file", zap.String("file", file), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// loadCsvFile Creates the table of a csv file and inserts its rows. Empty values are NULL
+func loadCsvFile(db *gorm.DB, table string, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("file has no header")
+	}
+	header, rows := records[0], records[1:]
+
+	quotedTable, err := sqlbuilder.QuoteIdentifier(table)
+	if err != nil {
+		return err
+	}
+	definitions := make([]string, len(header))
+	for i, column := range header {
+		quoted, err := sqlbuilder.QuoteIdentifier(strings.TrimSpace(column))
+		if err != nil {
+			return err
+		}
+		definitions[i] = quoted + " " + csvColumnType(rows, i)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(header)), ", ")
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(fmt.Sprintf("CREATE TABLE %s (%s)", quotedTable, strings.Join(definitions, ", "))).Error
+		if err != nil {
+			return err
+		}
+		insert := fmt.Sprintf("INSERT INTO %s VALUES (%s)", quotedTable, placeholders)
+		for _, row := range rows {
+			if len(row) != len(header) {
+				return fmt.Errorf("row has %d values instead of %d", len(row), len(header))
+			}
+			values := make([]interface{}, len(row))
+			for i, value := range row {
+				if value != "" {
+					values[i] = value
+				}
+			}
+			err = tx.Exec(insert, values...).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// csvColumnType Returns the narrowest type which fits every value of the column. The values are inserted as text
+// and SQLite converts them to the type of the column
+func csvColumnType(rows [][]string, column int) string {
+	columnType := csvTypeInteger
+	for _, row := range rows {
+		if column >= len(row) || row[column] == "" {
+			continue
+		}
+		value := row[column]
+		if columnType == csvTypeInteger {
+			if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+				continue
+			}
+			columnType = csvTypeReal
+		}
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return csvTypeText
+		}
+	}
+	return columnType
+}
@@ -0,0 +1,248 @@
+package datasource
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"github.com/mattn/go-sqlite3"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"os"
+	"scheduler/config"
+	"scheduler/internal/logger"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Drivers of the data sources. Csv sources load every csv file of their directory into an in-memory SQLite
+// database, so the jobs query all the sources with the same SQL
+const (
+	DriverSqlite = "sqlite"
+	DriverCsv    = "csv"
+)
+
+// DefaultName Name of the data source of the jobs which do not pick one. It has to be configured
+const DefaultName = "default"
+
+const (
+	defaultMaxConnections     = 10
+	defaultMaxIdleConnections = 2
+)
+
+// DataSource A named database the queries of the jobs run on, with its own connection pool. The connections are
+// read-only, so a job can never write to the data or lock it
+type DataSource struct {
+	Name   string
+	Driver string
+	// Path is the database file of sqlite sources and the directory of the files of csv sources
+	Path string
+	DB   *gorm.DB
+	// loader is the connection which loaded the files of a csv source. It keeps the in-memory database alive
+	loader *gorm.DB
+	// schemaDB is the connection which discovers the schema. Unlike the connections of DB it can read the schema
+	schemaDB *gorm.DB
+	// Access limits the tables and columns of the source which jobs can use
+	Access Access
+
+	schemaMutex    sync.Mutex
+	schema         []Table
+	schemaLoadedAt time.Time
+}
+
+var dataSources = make(map[string]*DataSource)
+var m sync.RWMutex
+
+// InitDataSources Opens the configured data sources. One of them has to be the default source
+func InitDataSources(configs []config.DataSource) error {
+	m.Lock()
+	defer m.Unlock()
+
+	sources := make(map[string]*DataSource)
+	for _, sourceConfig := range configs {
+		if sourceConfig.Name == "" {
+			return fmt.Errorf("data source without a name")
+		}
+		if _, ok := sources[sourceConfig.Name]; ok {
+			return fmt.Errorf("data source is configured twice: %s", sourceConfig.Name)
+		}
+		source, err := open(sourceConfig)
+		if err != nil {
+			return fmt.Errorf("unable to open the data source %s: %w", sourceConfig.Name, err)
+		}
+		sources[source.Name] = source
+		logger.Log.Info("Data source opened", zap.String("data_source", source.Name), zap.String("driver", source.Driver))
+	}
+	if _, ok := sources[DefaultName]; !ok {
+		return fmt.Errorf("no data source is configured with the name %s", DefaultName)
+	}
+	dataSources = sources
+	return nil
+}
+
+// GetDataSource Returns the data source with the given name. An empty name is the default source
+func GetDataSource(name string) (*DataSource, error) {
+	m.RLock()
+	defer m.RUnlock()
+	if name == "" {
+		name = DefaultName
+	}
+	source, ok := dataSources[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown data source: %s", name)
+	}
+	return source, nil
+}
+
+// GetDataSources Returns all the data sources, ordered by name
+func GetDataSources() []*DataSource {
+	m.RLock()
+	defer m.RUnlock()
+	sources := make([]*DataSource, 0, len(dataSources))
+	for _, source := range dataSources {
+		sources = append(sources, source)
+	}
+	slices.SortFunc(sources, func(a, b *DataSource) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return sources
+}
+
+// CloseDataSources Closes the connection pools of the data sources
+func CloseDataSources() {
+	m.Lock()
+	defer m.Unlock()
+	for _, source := range dataSources {
+		for _, db := range []*gorm.DB{source.DB, source.schemaDB, source.loader} {
+			if db == nil {
+				continue
+			}
+			sqlDB, err := db.DB()
+			if err != nil {
+				continue
+			}
+			if err = sqlDB.Close(); err != nil {
+				logger.Log.Warn("Unable to close the data source", zap.String("data_source", source.Name), zap.Error(err))
+			}
+		}
+	}
+}
+
+// HealthCheck Pings the connection pool of the source and checks that its files are still there
+func (d *DataSource) HealthCheck(ctx context.Context) error {
+	if d.Path != "" {
+		if _, err := os.Stat(d.Path); err != nil {
+			return err
+		}
+	}
+	sqlDB, err := d.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// open Opens the read-only connection pool of a data source. Sqlite sources open their file read-only, csv sources
+// load their files over a separate connection first since their in-memory database can not be opened read-only
+func open(sourceConfig config.DataSource) (*DataSource, error) {
+	if _, err := os.Stat(sourceConfig.Path); err != nil {
+		return nil, err
+	}
+	source := &DataSource{
+		Name:   sourceConfig.Name,
+		Driver: sourceConfig.Driver,
+		Path:   sourceConfig.Path,
+		Access: Access{Allow: sourceConfig.Allow, Deny: sourceConfig.Deny},
+	}
+	var dsn string
+	switch sourceConfig.Driver {
+	case DriverSqlite:
+		dsn = fmt.Sprintf("file:%s?mode=ro&_query_only=true", sourceConfig.Path)
+	case DriverCsv:
+		// every connection shares the in-memory database
+		memory := fmt.Sprintf("file:csv_%s?mode=memory&cache=shared", sourceConfig.Name)
+		loader, err := gorm.Open(sqlite.Open(memory), &gorm.Config{})
+		if err != nil {
+			return nil, err
+		}
+		source.loader = loader
+		err = loadCsvDirectory(loader, source.Path)
+		if err != nil {
+			return nil, err
+		}
+		dsn = memory + "&_query_only=true"
+	default:
+		return nil, fmt.Errorf("unsupported driver: %s", sourceConfig.Driver)
+	}
+	db, err := gorm.Open(sqlite.New(sqlite.Config{Conn: sql.OpenDB(source.connector(dsn, false))}), &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	source.DB = db
+	// the schema is only read over a connection of its own, so the queries of the jobs can never read it
+	schemaDB, err := gorm.Open(sqlite.New(sqlite.Config{Conn: sql.OpenDB(source.connector(dsn, true))}), &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	source.schemaDB = schemaDB
+	schemaSqlDB, err := schemaDB.DB()
+	if err != nil {
+		return nil, err
+	}
+	schemaSqlDB.SetMaxOpenConns(1)
+	schemaSqlDB.SetConnMaxLifetime(time.Hour)
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	maxConnections := sourceConfig.MaxConnections
+	if maxConnections <= 0 {
+		maxConnections = defaultMaxConnections
+	}
+	maxIdleConnections := sourceConfig.MaxIdleConnections
+	if maxIdleConnections <= 0 {
+		maxIdleConnections = defaultMaxIdleConnections
+	}
+	sqlDB.SetMaxOpenConns(maxConnections)
+	sqlDB.SetMaxIdleConns(maxIdleConnections)
+	sqlDB.SetConnMaxLifetime(time.Hour)
+	// connecting right away reports a missing or unreadable file at boot instead of on the first run
+	err = sqlDB.Ping()
+	if err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+// authorizedConnector Opens the connections of a data source with the authorizer of its access lists
+type authorizedConnector struct {
+	driver *sqlite3.SQLiteDriver
+	dsn    string
+}
+
+func (c authorizedConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c authorizedConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// connector Returns the connector of a connection pool of the source. Every connection it opens only reads the
+// tables and columns the access lists of the source allow, and the schema only when it is the schema connection
+func (d *DataSource) connector(dsn string, schema bool) driver.Connector {
+	authorize := d.Access.authorizer(schema)
+	return authorizedConnector{
+		driver: &sqlite3.SQLiteDriver{
+			ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+				conn.RegisterAuthorizer(authorize)
+				return nil
+			},
+		},
+		dsn: dsn,
+	}
+}
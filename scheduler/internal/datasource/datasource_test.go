@@ -0,0 +1,98 @@
+package datasource
+
+import (
+	"context"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"path/filepath"
+	"scheduler/config"
+	"testing"
+)
+
+// openTestSource Opens a sqlite source with a table of data, a table of the scheduler and the given access lists
+func openTestSource(t *testing.T, allow []string, deny []string) *DataSource {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "data.sqlite")
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("unable to create the database: %v", err)
+	}
+	for _, statement := range []string{
+		`CREATE TABLE registration (id INTEGER PRIMARY KEY, weight REAL, email TEXT)`,
+		`INSERT INTO registration (weight, email) VALUES (70.5, 'someone@example.com')`,
+		`CREATE TABLE cron_jobs (id INTEGER PRIMARY KEY, name TEXT)`,
+		`INSERT INTO cron_jobs (name) VALUES ('job')`,
+	} {
+		if err = db.Exec(statement).Error; err != nil {
+			t.Fatalf("unable to prepare the database: %v", err)
+		}
+	}
+	sqlDB, _ := db.DB()
+	_ = sqlDB.Close()
+
+	source, err := open(config.DataSource{Name: DefaultName, Driver: DriverSqlite, Path: path, Allow: allow, Deny: deny})
+	if err != nil {
+		t.Fatalf("unable to open the source: %v", err)
+	}
+	t.Cleanup(func() {
+		for _, db := range []*gorm.DB{source.DB, source.schemaDB} {
+			sqlDB, _ := db.DB()
+			_ = sqlDB.Close()
+		}
+	})
+	return source
+}
+
+func TestQueriesOnlyReadWhatTheAccessListsAllow(t *testing.T) {
+	source := openTestSource(t, nil, []string{"registration.email"})
+
+	allowed := []string{
+		`SELECT avg(weight) FROM registration`,
+		`SELECT count(*) FROM registration`,
+	}
+	for _, query := range allowed {
+		var rows []map[string]interface{}
+		if err := source.DB.Raw(query).Scan(&rows).Error; err != nil {
+			t.Errorf("%s: unexpected error: %v", query, err)
+		}
+	}
+	denied := []string{
+		`SELECT * FROM cron_jobs`,
+		`SELECT count(*) FROM cron_jobs`,
+		`SELECT email FROM registration`,
+		`SELECT * FROM registration`,
+		`WITH jobs AS (SELECT name FROM cron_jobs) SELECT weight FROM registration, jobs`,
+		`SELECT * FROM sqlite_master`,
+		`SELECT sql FROM sqlite_schema WHERE name = 'cron_jobs'`,
+		`SELECT * FROM pragma_table_info('cron_jobs')`,
+		`PRAGMA table_info(cron_jobs)`,
+	}
+	for _, query := range denied {
+		var rows []map[string]interface{}
+		if err := source.DB.Raw(query).Scan(&rows).Error; err == nil {
+			t.Errorf("%s: read %v", query, rows)
+		}
+	}
+}
+
+func TestAllowListLimitsTheTablesQueriesRead(t *testing.T) {
+	source := openTestSource(t, []string{"registration.weight"}, nil)
+
+	var weight float64
+	if err := source.DB.Raw(`SELECT weight FROM registration`).Scan(&weight).Error; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	var email string
+	if err := source.DB.Raw(`SELECT email FROM registration`).Scan(&email).Error; err == nil {
+		t.Errorf("read the email %q which is not allowed", email)
+	}
+
+	// the schema stays discoverable
+	tables, err := source.Tables(context.Background())
+	if err != nil {
+		t.Fatalf("unable to discover the tables: %v", err)
+	}
+	if len(tables) != 1 || tables[0].Name != "registration" || len(tables[0].Columns) != 1 {
+		t.Errorf("discovered %+v", tables)
+	}
+}
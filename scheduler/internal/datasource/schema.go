@@ -0,0 +1,239 @@
+package datasource
+
+import (
+	"context"
+	"fmt"
+	"github.com/mattn/go-sqlite3"
+	"scheduler/internal/sqlbuilder"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Kinds of the columns. Columns declared as text are temporal when their values are dates
+const (
+	KindNumeric  = "numeric"
+	KindTemporal = "temporal"
+	KindText     = "text"
+)
+
+// schemaTTL How long a discovered schema is used before the source is introspected again
+const schemaTTL = 30 * time.Second
+
+// schemaSampleSize The number of values of a text column which are sampled to classify it
+const schemaSampleSize = 100
+
+// schedulerTables Tables of the scheduler which are never listed, whatever the allow list of the source says
+var schedulerTables = []string{"cron_jobs", "job_runs", "backfills", "job_watermarks", "schema_migrations"}
+
+type Column struct {
+	Name string
+	// Type is the declared type of the column
+	Type string
+	Kind string
+}
+
+type Table struct {
+	Name    string
+	Columns []Column
+}
+
+// Access The tables and columns of a source which jobs can use. Entries are a table or a column as "table.column".
+// Without an allow list every table is allowed, the deny list always wins
+type Access struct {
+	Allow []string
+	Deny  []string
+}
+
+// allowsTable Reports whether the table can be used. The internal tables of SQLite and the tables of the scheduler
+// can never be used
+func (a Access) allowsTable(table string) bool {
+	if strings.HasPrefix(table, "sqlite_") || slices.Contains(schedulerTables, table) || slices.Contains(a.Deny, table) {
+		return false
+	}
+	if len(a.Allow) == 0 || slices.Contains(a.Allow, table) {
+		return true
+	}
+	// a table is allowed when one of its columns is
+	return slices.ContainsFunc(a.Allow, func(entry string) bool {
+		return strings.HasPrefix(entry, table+".")
+	})
+}
+
+// allowsColumn Reports whether the column of an allowed table can be used. Allowing a table allows all its columns
+func (a Access) allowsColumn(table string, column string) bool {
+	entry := table + "." + column
+	if slices.Contains(a.Deny, entry) {
+		return false
+	}
+	return len(a.Allow) == 0 || slices.Contains(a.Allow, table) || slices.Contains(a.Allow, entry)
+}
+
+// schemaTables Tables which describe the schema of a source. They are read to discover the tables jobs can use
+var schemaTables = []string{"sqlite_master", "sqlite_schema", "sqlite_temp_master", "sqlite_temp_schema"}
+
+// authorizer Returns the authorizer of the connections of a source. It denies the statements which read a table or a
+// column jobs can not use, so the access lists also hold for the queries of sql jobs. Only the connections which
+// discover the schema can read the schema tables and the pragma functions, the schema of the denied tables is never
+// exposed to the queries of the jobs
+func (a Access) authorizer(schema bool) func(int, string, string, string) int {
+	return func(action int, table string, column string, _ string) int {
+		if action == sqlite3.SQLITE_PRAGMA && !schema {
+			return sqlite3.SQLITE_DENY
+		}
+		if action != sqlite3.SQLITE_READ {
+			return sqlite3.SQLITE_OK
+		}
+		if slices.Contains(schemaTables, table) || strings.HasPrefix(table, "pragma_") {
+			if schema {
+				return sqlite3.SQLITE_OK
+			}
+			return sqlite3.SQLITE_DENY
+		}
+		// column is empty when no column of the table is read, like in count(*)
+		if !a.allowsTable(table) || (column != "" && !a.allowsColumn(table, column)) {
+			return sqlite3.SQLITE_DENY
+		}
+		return sqlite3.SQLITE_OK
+	}
+}
+
+// Tables Returns the tables and views of the source which jobs can use with their allowed columns, ordered by name.
+// The schema is discovered through sqlite_master and PRAGMA table_info over the schema connection of the source and
+// reused for a while
+func (d *DataSource) Tables(ctx context.Context) ([]Table, error) {
+	d.schemaMutex.Lock()
+	defer d.schemaMutex.Unlock()
+	if d.schema != nil && time.Since(d.schemaLoadedAt) < schemaTTL {
+		return d.schema, nil
+	}
+	tables, err := d.discoverTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	d.schema = tables
+	d.schemaLoadedAt = time.Now()
+	return tables, nil
+}
+
+// Table Returns a table of the source which jobs can use
+func (d *DataSource) Table(ctx context.Context, name string) (Table, bool, error) {
+	tables, err := d.Tables(ctx)
+	if err != nil {
+		return Table{}, false, err
+	}
+	for _, table := range tables {
+		if table.Name == name {
+			return table, true, nil
+		}
+	}
+	return Table{}, false, nil
+}
+
+func (d *DataSource) discoverTables(ctx context.Context) ([]Table, error) {
+	var names []string
+	err := d.schemaDB.WithContext(ctx).
+		Raw("SELECT name FROM sqlite_master WHERE type IN ('table', 'view') ORDER BY name").
+		Scan(&names).Error
+	if err != nil {
+		return nil, err
+	}
+	tables := make([]Table, 0, len(names))
+	for _, name := range names {
+		// jobs can only query tables whose name is a plain identifier
+		if _, err := sqlbuilder.QuoteIdentifier(name); err != nil || !d.Access.allowsTable(name) {
+			continue
+		}
+		var columns []Column
+		err = d.schemaDB.WithContext(ctx).Raw("SELECT name, type FROM pragma_table_info(?) ORDER BY cid", name).Scan(&columns).Error
+		if err != nil {
+			return nil, err
+		}
+		table := Table{Name: name}
+		for _, column := range columns {
+			if _, err := sqlbuilder.QuoteIdentifier(column.Name); err != nil || !d.Access.allowsColumn(name, column.Name) {
+				continue
+			}
+			column.Kind, err = d.classifyColumn(ctx, name, column)
+			if err != nil {
+				return nil, err
+			}
+			table.Columns = append(table.Columns, column)
+		}
+		if len(table.Columns) > 0 {
+			tables = append(tables, table)
+		}
+	}
+	return tables, nil
+}
+
+// classifyColumn Classifies the column by its declared type. Columns declared as text or without a type are
+// classified by a sample of their values, since SQLite stores dates as text
+func (d *DataSource) classifyColumn(ctx context.Context, table string, column Column) (string, error) {
+	declared := strings.ToUpper(column.Type)
+	switch {
+	case strings.Contains(declared, "DATE") || strings.Contains(declared, "TIME"):
+		return KindTemporal, nil
+	case containsAny(declared, "INT", "REAL", "FLOA", "DOUB", "NUM", "DEC"):
+		return KindNumeric, nil
+	case declared != "" && !containsAny(declared, "CHAR", "CLOB", "TEXT"):
+		return KindText, nil
+	}
+
+	var sample struct {
+		Total   int
+		Numbers int
+		Dates   int
+	}
+	err := d.schemaDB.WithContext(ctx).Raw(fmt.Sprintf(`SELECT count(*) AS total,
+	count(CASE WHEN typeof(value) IN ('integer', 'real') THEN 1 END) AS numbers,
+	count(CASE WHEN value GLOB '[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9]*' AND datetime(value) IS NOT NULL THEN 1 END) AS dates
+FROM (SELECT %[1]s AS value FROM %[2]s WHERE %[1]s IS NOT NULL LIMIT ?)`,
+		quote(column.Name), quote(table)), schemaSampleSize).Scan(&sample).Error
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case sample.Total == 0:
+		return KindText, nil
+	case sample.Numbers == sample.Total:
+		return KindNumeric, nil
+	case sample.Dates == sample.Total:
+		return KindTemporal, nil
+	default:
+		return KindText, nil
+	}
+}
+
+// Column Returns the column of the table with the given name
+func (t Table) Column(name string) (Column, bool) {
+	for _, column := range t.Columns {
+		if column.Name == name {
+			return column, true
+		}
+	}
+	return Column{}, false
+}
+
+// ColumnNames Returns the names of the columns of the table of the given kinds, all of them without kinds
+func (t Table) ColumnNames(kinds ...string) []string {
+	names := make([]string, 0, len(t.Columns))
+	for _, column := range t.Columns {
+		if len(kinds) == 0 || slices.Contains(kinds, column.Kind) {
+			names = append(names, column.Name)
+		}
+	}
+	return names
+}
+
+func containsAny(s string, substrings ...string) bool {
+	return slices.ContainsFunc(substrings, func(substring string) bool {
+		return strings.Contains(s, substring)
+	})
+}
+
+// quote Quotes a name which is known to be a plain identifier
+func quote(name string) string {
+	quoted, _ := sqlbuilder.QuoteIdentifier(name)
+	return quoted
+}
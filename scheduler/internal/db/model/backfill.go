@@ -0,0 +1,24 @@
+package model
+
+const (
+	BackfillStatusRunning     = "running"
+	BackfillStatusSucceeded   = "succeeded"
+	BackfillStatusFailed      = "failed"
+	BackfillStatusCancelled   = "cancelled"
+	BackfillStatusInterrupted = "interrupted"
+)
+
+// Backfill runs a job once per period of a historical date range. Every period is recorded as a JobRun of the backfill
+type Backfill struct {
+	ID               string `gorm:"primaryKey;type:text"`
+	JobID            uint   `gorm:"not null;index"`
+	StartDate        string `gorm:"type:text;not null"`
+	EndDate          string `gorm:"type:text;not null"`
+	Status           string `gorm:"type:text;not null;index"`
+	TotalPeriods     int    `gorm:"not null;default:0"`
+	CompletedPeriods int    `gorm:"not null;default:0"`
+	FailedPeriods    int    `gorm:"not null;default:0"`
+	CreatedAt        string `gorm:"type:text"`
+	FinishedAt       string `gorm:"type:text"`
+	Error            string `gorm:"type:text"`
+}
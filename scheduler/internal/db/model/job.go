@@ -1,5 +1,63 @@
 package model
 
+const (
+	// ConcurrencyPolicyAllow lets a fire start while the previous run is still in progress
+	ConcurrencyPolicyAllow = "allow"
+	// ConcurrencyPolicySkip drops a fire while the previous run is still in progress
+	ConcurrencyPolicySkip = "skip"
+	// ConcurrencyPolicyQueue delays a fire until the previous run is finished
+	ConcurrencyPolicyQueue = "queue"
+)
+
+const (
+	// MisfirePolicyIgnore skips the fire times missed while the service was down
+	MisfirePolicyIgnore = "ignore"
+	// MisfirePolicyRunOnce runs the job once for the most recent missed fire time
+	MisfirePolicyRunOnce = "run_once"
+	// MisfirePolicyRunAll runs the job for every missed fire time
+	MisfirePolicyRunAll = "run_all"
+)
+
+const (
+	// JobStatusActive jobs are scheduled
+	JobStatusActive = "active"
+	// JobStatusPaused jobs are kept but not scheduled until they are resumed
+	JobStatusPaused = "paused"
+	// JobStatusDeleted jobs are retired and only kept for their history
+	JobStatusDeleted = "deleted"
+)
+
+const (
+	// JobTypeAggregation jobs aggregate a field of a table over the window of their duration
+	JobTypeAggregation = "aggregation"
+	// JobTypeSql jobs run their own read-only query with the period of their duration bound to named parameters
+	JobTypeSql = "sql"
+)
+
+// Buckets of the time series of bucketed jobs
+const (
+	BucketHour    = "hour"
+	BucketDay     = "day"
+	BucketWeek    = "week"
+	BucketMonth   = "month"
+	BucketQuarter = "quarter"
+	BucketYear    = "year"
+)
+
+// Operators of the filters of a job
+const (
+	FilterOperatorEq      = "eq"
+	FilterOperatorNe      = "ne"
+	FilterOperatorGt      = "gt"
+	FilterOperatorGte     = "gte"
+	FilterOperatorLt      = "lt"
+	FilterOperatorLte     = "lte"
+	FilterOperatorBetween = "between"
+	FilterOperatorIn      = "in"
+	FilterOperatorIsNull  = "is_null"
+	FilterOperatorNotNull = "not_null"
+)
+
 type CronJob struct {
 	ID             uint   `gorm:"primaryKey;autoIncrement"`
 	Name           string `gorm:"type:text;not null"`
@@ -10,7 +68,46 @@ type CronJob struct {
 	Aggregation    string `gorm:"type:text;not null"`
 	Duration       string `gorm:"type:text;not null"`
 	DurationFilter string `gorm:"type:text;not null"`
-	CreatedAt      string `gorm:"type:datetime"`
-	LastRun        string `gorm:"type:datetime"`
-	NextRun        string `gorm:"type:datetime"`
+	CreatedAt      string `gorm:"type:text"`
+	LastRun        string `gorm:"type:text"`
+	NextRun        string `gorm:"type:text"`
+
+	// Status is one of active, paused or deleted. Enabled is kept in sync and is only true for active jobs
+	Status string `gorm:"type:text;not null;default:'active';index"`
+
+	// Timezone is the IANA timezone the cron expression is evaluated in. Empty means the timezone of the scheduler.
+	// CreatedAt, LastRun and NextRun are always stored in UTC
+	Timezone string `gorm:"type:text"`
+
+	ConcurrencyPolicy string `gorm:"type:text;not null;default:'allow'"`
+	MisfirePolicy     string `gorm:"type:text;not null;default:'ignore'"`
+	// MaxRuntime is the duration (e.g. "5m") after which a run is aborted. Empty means the default of the scheduler
+	MaxRuntime string `gorm:"type:text"`
+
+	// Retry policy for posting the result. Zero values fall back to the defaults of the scheduler
+	RetryMaxAttempts    int     `gorm:"not null;default:1"`
+	RetryInitialBackoff string  `gorm:"type:text"`
+	RetryMultiplier     float64 `gorm:"not null;default:0"`
+	RetryMaxBackoff     string  `gorm:"type:text"`
+	RetryableStatuses   IntList `gorm:"type:text"`
+
+	// Type is one of aggregation or sql. Query is only set for sql jobs, which leave Table, Field, Aggregation
+	// and DurationFilter empty
+	Type  string `gorm:"type:text;not null;default:'aggregation'"`
+	Query string `gorm:"type:text"`
+
+	// Parameters are the values of the parameters the strategy of the duration declares, e.g. the bucket and the
+	// lookback of bucketed jobs
+	Parameters ParameterMap `gorm:"type:text"`
+
+	// Measures are the aggregations of aggregation jobs which compute more than one. Field and Aggregation hold the
+	// first measure then. Jobs without measures post their single aggregation under the alias of their strategy
+	Measures MeasureList `gorm:"type:text"`
+	// Filters restrict the rows aggregation jobs aggregate, on top of the window of their duration
+	Filters FilterList `gorm:"type:text"`
+	// DataSource is the name of the data source the query runs on. It is the default source when it is empty
+	DataSource string `gorm:"type:text"`
+	// WatermarkColumn makes the job incremental: every run only recomputes the buckets of the rows whose value of the
+	// column is after the watermark of the job. It is empty for jobs which recompute everything on every run
+	WatermarkColumn string `gorm:"type:text"`
 }
@@ -0,0 +1,38 @@
+package model
+
+// Interrupted runs were aborted by a shutdown and are resumed on the next boot
+const (
+	RunStatusRunning     = "running"
+	RunStatusSucceeded   = "succeeded"
+	RunStatusFailed      = "failed"
+	RunStatusSkipped     = "skipped"
+	RunStatusCancelled   = "cancelled"
+	RunStatusTimedOut    = "timed_out"
+	RunStatusInterrupted = "interrupted"
+)
+
+const (
+	RunTriggerSchedule = "schedule"
+	RunTriggerManual   = "manual"
+	RunTriggerCatchUp  = "catch_up"
+	RunTriggerResume   = "resume"
+	RunTriggerBackfill = "backfill"
+)
+
+// JobRun is a single execution of a CronJob. ResumedBy is the id of the run which resumed an interrupted run and
+// BackfillID the backfill the run belongs to
+type JobRun struct {
+	ID          string `gorm:"primaryKey;type:text"`
+	JobID       uint   `gorm:"not null;index"`
+	Trigger     string `gorm:"type:text;not null;default:'schedule'"`
+	ScheduledAt string `gorm:"type:text"`
+	StartedAt   string `gorm:"type:text"`
+	FinishedAt  string `gorm:"type:text"`
+	Status      string `gorm:"type:text;not null;index"`
+	RowCount    int    `gorm:"not null;default:0"`
+	Error       string `gorm:"type:text"`
+	HttpStatus  int    `gorm:"not null;default:0"`
+	Attempts    int    `gorm:"not null;default:0"`
+	ResumedBy   string `gorm:"type:text"`
+	BackfillID  string `gorm:"type:text;index"`
+}
@@ -0,0 +1,169 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// IntList is a list of integers stored as a JSON array in a text column
+type IntList []int
+
+func (l IntList) Value() (driver.Value, error) {
+	if l == nil {
+		return nil, nil
+	}
+	value, err := json.Marshal([]int(l))
+	if err != nil {
+		return nil, err
+	}
+	return string(value), nil
+}
+
+func (l *IntList) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case string:
+		if v == "" {
+			*l = nil
+			return nil
+		}
+		return json.Unmarshal([]byte(v), l)
+	case []byte:
+		return json.Unmarshal(v, l)
+	default:
+		return fmt.Errorf("unsupported type %T for IntList", value)
+	}
+}
+
+// Measure is a single aggregation of a field which is posted under its alias
+type Measure struct {
+	Field       string `json:"field"`
+	Aggregation string `json:"aggregation"`
+	Alias       string `json:"alias"`
+}
+
+// MeasureList is a list of measures stored as a JSON array in a text column
+type MeasureList []Measure
+
+func (l MeasureList) Value() (driver.Value, error) {
+	if l == nil {
+		return nil, nil
+	}
+	value, err := json.Marshal([]Measure(l))
+	if err != nil {
+		return nil, err
+	}
+	return string(value), nil
+}
+
+func (l *MeasureList) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case string:
+		if v == "" {
+			*l = nil
+			return nil
+		}
+		return json.Unmarshal([]byte(v), l)
+	case []byte:
+		return json.Unmarshal(v, l)
+	default:
+		return fmt.Errorf("unsupported type %T for MeasureList", value)
+	}
+}
+
+// Filter is a predicate on a field of the table. Value is a single value, a list of two values for between, a list
+// for in and empty for is_null and not_null
+type Filter struct {
+	Field    string      `json:"field"`
+	Operator string      `json:"operator"`
+	Value    interface{} `json:"value,omitempty"`
+}
+
+// FilterList is a list of filters stored as a JSON array in a text column
+type FilterList []Filter
+
+func (l FilterList) Value() (driver.Value, error) {
+	if l == nil {
+		return nil, nil
+	}
+	value, err := json.Marshal([]Filter(l))
+	if err != nil {
+		return nil, err
+	}
+	return string(value), nil
+}
+
+func (l *FilterList) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case string:
+		if v == "" {
+			*l = nil
+			return nil
+		}
+		return json.Unmarshal([]byte(v), l)
+	case []byte:
+		return json.Unmarshal(v, l)
+	default:
+		return fmt.Errorf("unsupported type %T for FilterList", value)
+	}
+}
+
+// ParameterMap holds the values of the parameters the strategy of a job declares, keyed by the name of the parameter.
+// It is stored as a JSON object in a text column, so integers are read back as numbers
+type ParameterMap map[string]interface{}
+
+func (p ParameterMap) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
+	}
+	value, err := json.Marshal(map[string]interface{}(p))
+	if err != nil {
+		return nil, err
+	}
+	return string(value), nil
+}
+
+func (p *ParameterMap) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*p = nil
+		return nil
+	case string:
+		if v == "" {
+			*p = nil
+			return nil
+		}
+		return json.Unmarshal([]byte(v), p)
+	case []byte:
+		return json.Unmarshal(v, p)
+	default:
+		return fmt.Errorf("unsupported type %T for ParameterMap", value)
+	}
+}
+
+// String Returns the value of a string parameter, empty when it is not set
+func (p ParameterMap) String(name string) string {
+	value, _ := p[name].(string)
+	return value
+}
+
+// Int Returns the value of an integer parameter, 0 when it is not set
+func (p ParameterMap) Int(name string) int {
+	switch value := p[name].(type) {
+	case int:
+		return value
+	case float64:
+		return int(value)
+	default:
+		return 0
+	}
+}
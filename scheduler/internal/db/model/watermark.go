@@ -0,0 +1,17 @@
+package model
+
+// Watermark The highest value of the watermark column of a job which the delivered results of the job cover. The
+// next incremental run only recomputes the buckets of the rows after it
+type Watermark struct {
+	JobID  uint   `gorm:"primaryKey;autoIncrement:false"`
+	Column string `gorm:"type:text;not null"`
+	Value  string `gorm:"type:text;not null"`
+	// Numeric is set when the value is a number. It is bound as a number then, so it compares with the column as one
+	Numeric   bool   `gorm:"not null;default:false"`
+	RunID     string `gorm:"type:text"`
+	UpdatedAt string `gorm:"type:text"`
+}
+
+func (Watermark) TableName() string {
+	return "job_watermarks"
+}
@@ -0,0 +1,112 @@
+package sqlite
+
+import (
+	"fmt"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"os"
+	"scheduler/internal/db/model"
+	"scheduler/internal/logger"
+	"slices"
+	"strings"
+)
+
+// stateTables The tables of the state of the scheduler, in the order they are imported
+var stateTables = []string{"cron_jobs", "job_runs", "backfills", "job_watermarks"}
+
+// ImportState Copies the jobs, runs and backfills of the database the state used to be kept in into the state store.
+// Nothing is imported once the store has jobs, so the import only happens on the first boot of the store
+func ImportState(path string) error {
+	if path == "" {
+		return nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	db := GetSqliteDB()
+	var jobs int64
+	err := db.Model(&model.CronJob{}).Count(&jobs).Error
+	if err != nil || jobs > 0 {
+		return err
+	}
+
+	// attached databases only exist on the connection which attached them
+	return db.Connection(func(conn *gorm.DB) error {
+		err := conn.Exec("ATTACH DATABASE ? AS legacy", path).Error
+		if err != nil {
+			return err
+		}
+		defer conn.Exec("DETACH DATABASE legacy")
+
+		return conn.Transaction(func(tx *gorm.DB) error {
+			for _, table := range stateTables {
+				rows, legacyColumns, err := importTable(tx, table)
+				if err != nil {
+					return fmt.Errorf("unable to import %s: %w", table, err)
+				}
+				if rows == 0 {
+					continue
+				}
+				logger.Log.Info("Imported the state of the scheduler", zap.String("table", table), zap.Int64("rows", rows))
+				if table != "cron_jobs" {
+					continue
+				}
+				// the imported jobs still need the backfills of the columns their table did not have yet
+				for _, column := range cronJobColumns {
+					if column.backfill == "" || slices.Contains(legacyColumns, tx.NamingStrategy.ColumnName("", column.field)) {
+						continue
+					}
+					err = tx.Exec(column.backfill).Error
+					if err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// importTable Copies the columns the table has in both databases and returns the number of rows and the columns of
+// the legacy table. Columns the legacy table lacks get their default, and so do the nulls of the legacy columns which
+// can not be null in the store, an empty value when the column has no default
+func importTable(tx *gorm.DB, table string) (int64, []string, error) {
+	var legacyColumns []string
+	err := tx.Raw("SELECT name FROM pragma_table_info(?, 'legacy')", table).Scan(&legacyColumns).Error
+	if err != nil || len(legacyColumns) == 0 {
+		return 0, nil, err
+	}
+	var columns []struct {
+		Name      string
+		NotNull   bool
+		DfltValue *string
+	}
+	err = tx.Raw("SELECT name, \"notnull\" AS not_null, dflt_value FROM pragma_table_info(?, 'main')", table).Scan(&columns).Error
+	if err != nil {
+		return 0, nil, err
+	}
+	var names, values []string
+	for _, column := range columns {
+		if !slices.Contains(legacyColumns, column.Name) {
+			continue
+		}
+		name := `"` + column.Name + `"`
+		value := name
+		if column.NotNull {
+			fallback := "''"
+			if column.DfltValue != nil {
+				fallback = *column.DfltValue
+			}
+			value = fmt.Sprintf("coalesce(%s, %s)", name, fallback)
+		}
+		names = append(names, name)
+		values = append(values, value)
+	}
+	// the names and defaults come from the schema of the tables of the scheduler, not from a request
+	result := tx.Exec(fmt.Sprintf(`INSERT INTO main."%s" (%s) SELECT %s FROM legacy."%[1]s"`,
+		table, strings.Join(names, ", "), strings.Join(values, ", ")))
+	return result.RowsAffected, legacyColumns, result.Error
+}
@@ -0,0 +1,73 @@
+package sqlite
+
+import (
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"path/filepath"
+	"scheduler/internal/db/model"
+	"testing"
+)
+
+// legacySchema The cron_jobs table of the database the state was kept in before it had its own store
+const legacySchema = `CREATE TABLE "cron_jobs" (
+	"id" INTEGER,
+	"name" TEXT NOT NULL,
+	"cron_expression" TEXT NOT NULL,
+	"enabled" BOOLEAN NOT NULL DEFAULT 1,
+	"table" TEXT,
+	"field" TEXT NOT NULL,
+	"aggregation" TEXT NOT NULL,
+	"duration" TEXT NOT NULL,
+	"duration_filter" TEXT NOT NULL,
+	"created_at" TEXT,
+	"last_run" TEXT,
+	"next_run" TEXT,
+	PRIMARY KEY("id" AUTOINCREMENT)
+)`
+
+func TestImportedJobsKeepTheLayoutOfTimestampsAndGetTheirDefaults(t *testing.T) {
+	const stored = "2025-05-07 21:17:00"
+	legacyPath := filepath.Join(t.TempDir(), "db.sqlite")
+	legacy, err := gorm.Open(sqlite.Open(legacyPath), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("unable to open the legacy database: %v", err)
+	}
+	err = legacy.Exec(legacySchema).Error
+	if err == nil {
+		err = legacy.Exec(`INSERT INTO cron_jobs (name, cron_expression, enabled, "table", field, aggregation, duration,
+			duration_filter, created_at, last_run, next_run) VALUES ('job', '*/5 * * * *', 1, 'registration', 'weight',
+			'avg', 'daily', 'timestamp', ?, ?, ?)`, stored, stored, stored).Error
+	}
+	if err == nil {
+		// the table of the legacy jobs can be null
+		err = legacy.Exec(`INSERT INTO cron_jobs (name, cron_expression, enabled, field, aggregation, duration,
+			duration_filter) VALUES ('without table', '*/5 * * * *', 0, 'weight', 'avg', 'daily', 'timestamp')`).Error
+	}
+	if err != nil {
+		t.Fatalf("unable to prepare the legacy database: %v", err)
+	}
+	sqlDB, _ := legacy.DB()
+	_ = sqlDB.Close()
+
+	db := openStore(t)
+	if err = MigrateDatabase(); err != nil {
+		t.Fatalf("unable to migrate: %v", err)
+	}
+	if err = ImportState(legacyPath); err != nil {
+		t.Fatalf("unable to import: %v", err)
+	}
+	var jobs []model.CronJob
+	if err = db.Find(&jobs).Error; err != nil {
+		t.Fatalf("unable to read the jobs: %v", err)
+	}
+	if len(jobs) != 2 {
+		t.Fatalf("imported %d jobs, want 2", len(jobs))
+	}
+	if jobs[0].Status != model.JobStatusActive {
+		t.Errorf("imported job has status %q", jobs[0].Status)
+	}
+	assertStoredTimes(t, jobs[0], stored)
+	if jobs[1].Table != "" || jobs[1].Status != model.JobStatusDeleted {
+		t.Errorf("imported job without table has table %q and status %q", jobs[1].Table, jobs[1].Status)
+	}
+}
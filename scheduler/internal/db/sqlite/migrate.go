@@ -0,0 +1,301 @@
+package sqlite
+
+import (
+	"embed"
+	"fmt"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"io/fs"
+	"regexp"
+	"scheduler/internal/db/model"
+	"scheduler/internal/helper"
+	"scheduler/internal/logger"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// migrationFiles The migrations of the state store, as <version>_<name>.up.sql and <version>_<name>.down.sql
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// migrationsTable The table the applied versions are recorded in
+const migrationsTable = "schema_migrations"
+
+// baselineVersion The version which creates the tables the scheduler kept before its schema was versioned
+const baselineVersion = 1
+
+// Migration A version of the schema of the state store
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus A migration and when it was applied. AppliedAt is empty for pending migrations
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt string
+}
+
+// appliedMigration A row of the migrations table
+type appliedMigration struct {
+	Version   int    `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"type:text;not null"`
+	AppliedAt string `gorm:"type:text;not null"`
+}
+
+func (appliedMigration) TableName() string {
+	return migrationsTable
+}
+
+type columnMigration struct {
+	field string
+	// backfill is executed once, right after the column is added
+	backfill string
+}
+
+// cronJobColumns Columns of the baseline which the cron_jobs table of the stores older than the versioned schema
+// lacks. They get them when they are adopted
+var cronJobColumns = []columnMigration{
+	{field: "RetryMaxAttempts"},
+	{field: "RetryInitialBackoff"},
+	{field: "RetryMultiplier"},
+	{field: "RetryMaxBackoff"},
+	{field: "RetryableStatuses"},
+	{field: "ConcurrencyPolicy"},
+	{field: "Timezone"},
+	{field: "MisfirePolicy"},
+	{field: "MaxRuntime"},
+	{field: "Status", backfill: "UPDATE cron_jobs SET status = 'deleted' WHERE enabled = 0"},
+	{field: "Type"},
+	{field: "Query"},
+	{field: "Parameters"},
+	{field: "Measures"},
+	{field: "Filters"},
+	{field: "DataSource"},
+}
+
+// Migrations Returns the embedded migrations ordered by version. Every version needs an up and a down file
+func Migrations() ([]Migration, error) {
+	files, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int]*Migration)
+	for _, file := range files {
+		match := migrationFilePattern.FindStringSubmatch(file.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", file.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		content, err := migrationFiles.ReadFile("migrations/" + file.Name())
+		if err != nil {
+			return nil, err
+		}
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d needs an up and a down file", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return a.Version - b.Version
+	})
+	return migrations, nil
+}
+
+// MigrateDatabase Applies the migrations which are not applied yet, in order. Every migration is applied in its own
+// transaction together with the record of its version
+func MigrateDatabase() error {
+	db := GetSqliteDB()
+	migrations, err := Migrations()
+	if err != nil {
+		return err
+	}
+	err = prepareMigrationsTable(db)
+	if err != nil {
+		return err
+	}
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return err
+	}
+	for _, migration := range migrations {
+		if applied[migration.Version] {
+			continue
+		}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			err := tx.Exec(migration.Up).Error
+			if err != nil {
+				return err
+			}
+			return recordMigration(tx, migration)
+		})
+		if err != nil {
+			return fmt.Errorf("unable to apply migration %d %s: %w", migration.Version, migration.Name, err)
+		}
+		logger.Log.Info("Migration applied", zap.Int("version", migration.Version), zap.String("name", migration.Name))
+	}
+	return nil
+}
+
+// RollbackDatabase Rolls back the given number of the most recently applied migrations
+func RollbackDatabase(steps int) error {
+	db := GetSqliteDB()
+	migrations, err := Migrations()
+	if err != nil {
+		return err
+	}
+	err = prepareMigrationsTable(db)
+	if err != nil {
+		return err
+	}
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return err
+	}
+	for i := len(migrations) - 1; i >= 0 && steps > 0; i-- {
+		migration := migrations[i]
+		if !applied[migration.Version] {
+			continue
+		}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			err := tx.Exec(migration.Down).Error
+			if err != nil {
+				return err
+			}
+			return tx.Delete(&appliedMigration{Version: migration.Version}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("unable to roll back migration %d %s: %w", migration.Version, migration.Name, err)
+		}
+		logger.Log.Info("Migration rolled back", zap.Int("version", migration.Version), zap.String("name", migration.Name))
+		steps--
+	}
+	return nil
+}
+
+// MigrationStatuses Returns every migration with the time it was applied
+func MigrationStatuses() ([]MigrationStatus, error) {
+	db := GetSqliteDB()
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	var applied []appliedMigration
+	if db.Migrator().HasTable(migrationsTable) {
+		err = db.Find(&applied).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		for _, row := range applied {
+			if row.Version == migration.Version {
+				status.AppliedAt = row.AppliedAt
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// prepareMigrationsTable Creates the migrations table. A store which already has the cron_jobs table but no
+// migrations table is older than the versioned schema: it gets the columns and the tables of the baseline and the
+// baseline is recorded as applied
+func prepareMigrationsTable(db *gorm.DB) error {
+	if db.Migrator().HasTable(migrationsTable) {
+		return nil
+	}
+	unversioned := db.Migrator().HasTable(&model.CronJob{})
+	err := db.Migrator().CreateTable(&appliedMigration{})
+	if err != nil || !unversioned {
+		return err
+	}
+
+	migrations, err := Migrations()
+	if err != nil {
+		return err
+	}
+	index := slices.IndexFunc(migrations, func(migration Migration) bool {
+		return migration.Version == baselineVersion
+	})
+	if index < 0 {
+		return fmt.Errorf("baseline migration %d is missing", baselineVersion)
+	}
+	baseline := migrations[index]
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := adoptUnversionedStore(tx, baseline)
+		if err != nil {
+			return err
+		}
+		logger.Log.Info("Adopted the unversioned schema", zap.Int("version", baseline.Version))
+		return recordMigration(tx, baseline)
+	})
+}
+
+// adoptUnversionedStore Brings the cron_jobs table of a store which is older than the versioned schema up to the
+// baseline. The baseline then only creates the tables and indexes the store does not have yet
+func adoptUnversionedStore(tx *gorm.DB, baseline Migration) error {
+	for _, column := range cronJobColumns {
+		if tx.Migrator().HasColumn(&model.CronJob{}, column.field) {
+			continue
+		}
+		err := tx.Migrator().AddColumn(&model.CronJob{}, column.field)
+		if err != nil {
+			return err
+		}
+		if column.backfill == "" {
+			continue
+		}
+		err = tx.Exec(column.backfill).Error
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Exec(baseline.Up).Error
+}
+
+func appliedVersions(db *gorm.DB) (map[int]bool, error) {
+	var rows []appliedMigration
+	err := db.Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[int]bool)
+	for _, row := range rows {
+		applied[row.Version] = true
+	}
+	return applied, nil
+}
+
+func recordMigration(tx *gorm.DB, migration Migration) error {
+	return tx.Create(&appliedMigration{
+		Version:   migration.Version,
+		Name:      migration.Name,
+		AppliedAt: time.Now().UTC().Format(helper.TimeLayout),
+	}).Error
+}
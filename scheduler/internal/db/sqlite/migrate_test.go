@@ -0,0 +1,110 @@
+package sqlite
+
+import (
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"path/filepath"
+	"scheduler/internal/db/model"
+	"scheduler/internal/helper"
+	"scheduler/internal/logger"
+	"testing"
+)
+
+// openStore Opens an empty state store in a temporary directory as the store of the package
+func openStore(t *testing.T) *gorm.DB {
+	t.Helper()
+	logger.Log = zap.NewNop()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "scheduler.sqlite")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("unable to open the store: %v", err)
+	}
+	DB = db
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		_ = sqlDB.Close()
+		DB = nil
+	})
+	return db
+}
+
+// assertStoredTimes Checks that the timestamps of the job are read back as they were written
+func assertStoredTimes(t *testing.T, job model.CronJob, want string) {
+	t.Helper()
+	for column, value := range map[string]string{"created_at": job.CreatedAt, "last_run": job.LastRun, "next_run": job.NextRun} {
+		if value != want {
+			t.Errorf("%s: read back %q, want %q", column, value, want)
+		}
+		if _, err := helper.ParseStoredTime(value); err != nil {
+			t.Errorf("%s: %v", column, err)
+		}
+	}
+}
+
+func TestMigratedStoreKeepsTheLayoutOfTimestamps(t *testing.T) {
+	db := openStore(t)
+	if err := MigrateDatabase(); err != nil {
+		t.Fatalf("unable to migrate: %v", err)
+	}
+
+	const stored = "2025-05-07 21:17:00"
+	job := model.CronJob{
+		Name: "job", CronExpression: "*/5 * * * *", Table: "registration", Field: "weight", Aggregation: "avg",
+		Duration: "daily", DurationFilter: "timestamp", CreatedAt: stored, LastRun: stored, NextRun: stored,
+	}
+	if err := db.Create(&job).Error; err != nil {
+		t.Fatalf("unable to create the job: %v", err)
+	}
+	var read model.CronJob
+	if err := db.First(&read, job.ID).Error; err != nil {
+		t.Fatalf("unable to read the job: %v", err)
+	}
+	assertStoredTimes(t, read, stored)
+}
+
+func TestParseStoredTimeAcceptsTimestampsOfDatetimeColumns(t *testing.T) {
+	parsed, err := helper.ParseStoredTime("2025-05-07T21:17:00Z")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := parsed.Format(helper.TimeLayout); got != "2025-05-07 21:17:00" {
+		t.Errorf("parsed %s", got)
+	}
+}
+
+func TestMigrationAdoptsTheUnversionedStore(t *testing.T) {
+	db := openStore(t)
+	err := db.Exec(legacySchema).Error
+	if err == nil {
+		err = db.Exec(`INSERT INTO cron_jobs (name, cron_expression, enabled, "table", field, aggregation, duration,
+			duration_filter) VALUES ('job', '*/5 * * * *', 0, 'registration', 'weight', 'avg', 'daily', 'timestamp')`).Error
+	}
+	if err != nil {
+		t.Fatalf("unable to prepare the store: %v", err)
+	}
+	if err = MigrateDatabase(); err != nil {
+		t.Fatalf("unable to migrate: %v", err)
+	}
+
+	statuses, err := MigrationStatuses()
+	if err != nil {
+		t.Fatalf("unable to read the migrations: %v", err)
+	}
+	for _, status := range statuses {
+		if status.AppliedAt == "" {
+			t.Errorf("migration %d %s is not applied", status.Version, status.Name)
+		}
+	}
+	var job model.CronJob
+	if err = db.First(&job).Error; err != nil {
+		t.Fatalf("unable to read the job: %v", err)
+	}
+	if job.Status != model.JobStatusDeleted || job.ConcurrencyPolicy != model.ConcurrencyPolicyAllow {
+		t.Errorf("adopted job has status %q and concurrency policy %q", job.Status, job.ConcurrencyPolicy)
+	}
+	for _, table := range []interface{}{&model.JobRun{}, &model.Backfill{}, &model.Watermark{}} {
+		if !db.Migrator().HasTable(table) {
+			t.Errorf("%T has no table", table)
+		}
+	}
+}
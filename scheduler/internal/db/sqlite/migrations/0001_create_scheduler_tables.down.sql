@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "backfills";
+DROP TABLE IF EXISTS "job_runs";
+DROP TABLE IF EXISTS "cron_jobs";
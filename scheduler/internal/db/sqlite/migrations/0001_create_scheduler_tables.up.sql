@@ -0,0 +1,66 @@
+CREATE TABLE IF NOT EXISTS "cron_jobs" (
+	"id" integer PRIMARY KEY AUTOINCREMENT,
+	"name" text NOT NULL,
+	"cron_expression" text NOT NULL,
+	"enabled" numeric NOT NULL DEFAULT true,
+	"table" text NOT NULL,
+	"field" text NOT NULL,
+	"aggregation" text NOT NULL,
+	"duration" text NOT NULL,
+	"duration_filter" text NOT NULL,
+	"created_at" text,
+	"last_run" text,
+	"next_run" text,
+	"status" text NOT NULL DEFAULT 'active',
+	"timezone" text,
+	"concurrency_policy" text NOT NULL DEFAULT 'allow',
+	"misfire_policy" text NOT NULL DEFAULT 'ignore',
+	"max_runtime" text,
+	"retry_max_attempts" integer NOT NULL DEFAULT 1,
+	"retry_initial_backoff" text,
+	"retry_multiplier" real NOT NULL DEFAULT 0,
+	"retry_max_backoff" text,
+	"retryable_statuses" text,
+	"type" text NOT NULL DEFAULT 'aggregation',
+	"query" text,
+	"parameters" text,
+	"measures" text,
+	"filters" text,
+	"data_source" text
+);
+CREATE INDEX IF NOT EXISTS "idx_cron_jobs_status" ON "cron_jobs" ("status");
+
+CREATE TABLE IF NOT EXISTS "job_runs" (
+	"id" text PRIMARY KEY,
+	"job_id" integer NOT NULL,
+	"trigger" text NOT NULL DEFAULT 'schedule',
+	"scheduled_at" text,
+	"started_at" text,
+	"finished_at" text,
+	"status" text NOT NULL,
+	"row_count" integer NOT NULL DEFAULT 0,
+	"error" text,
+	"http_status" integer NOT NULL DEFAULT 0,
+	"attempts" integer NOT NULL DEFAULT 0,
+	"resumed_by" text,
+	"backfill_id" text
+);
+CREATE INDEX IF NOT EXISTS "idx_job_runs_job_id" ON "job_runs" ("job_id");
+CREATE INDEX IF NOT EXISTS "idx_job_runs_status" ON "job_runs" ("status");
+CREATE INDEX IF NOT EXISTS "idx_job_runs_backfill_id" ON "job_runs" ("backfill_id");
+
+CREATE TABLE IF NOT EXISTS "backfills" (
+	"id" text PRIMARY KEY,
+	"job_id" integer NOT NULL,
+	"start_date" text NOT NULL,
+	"end_date" text NOT NULL,
+	"status" text NOT NULL,
+	"total_periods" integer NOT NULL DEFAULT 0,
+	"completed_periods" integer NOT NULL DEFAULT 0,
+	"failed_periods" integer NOT NULL DEFAULT 0,
+	"created_at" text,
+	"finished_at" text,
+	"error" text
+);
+CREATE INDEX IF NOT EXISTS "idx_backfills_job_id" ON "backfills" ("job_id");
+CREATE INDEX IF NOT EXISTS "idx_backfills_status" ON "backfills" ("status");
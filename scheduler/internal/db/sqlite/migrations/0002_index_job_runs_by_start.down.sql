@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "idx_job_runs_job_id_started_at";
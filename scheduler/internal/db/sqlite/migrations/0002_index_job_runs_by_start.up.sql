@@ -0,0 +1,2 @@
+-- the runs of a job are listed from the most recent start
+CREATE INDEX IF NOT EXISTS "idx_job_runs_job_id_started_at" ON "job_runs" ("job_id", "started_at");
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "job_watermarks";
+ALTER TABLE "cron_jobs" DROP COLUMN "watermark_column";
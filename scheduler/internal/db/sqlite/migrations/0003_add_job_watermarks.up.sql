@@ -0,0 +1,10 @@
+ALTER TABLE "cron_jobs" ADD COLUMN "watermark_column" text;
+
+CREATE TABLE IF NOT EXISTS "job_watermarks" (
+	"job_id" integer PRIMARY KEY,
+	"column" text NOT NULL,
+	"value" text NOT NULL,
+	"numeric" numeric NOT NULL DEFAULT false,
+	"run_id" text,
+	"updated_at" text
+);
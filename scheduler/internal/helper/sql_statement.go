@@ -0,0 +1,100 @@
+package helper
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// forbiddenKeywords Keywords which are not allowed anywhere in the query of a sql job. ATTACH and DETACH are
+// reported as read-only by SQLite, so they are rejected here
+var forbiddenKeywords = []string{"ATTACH", "DETACH", "PRAGMA"}
+
+// ValidateSelectStatement Checks that the query is a single SELECT or WITH statement without forbidden keywords.
+// String literals, quoted identifiers and comments are skipped, a trailing semicolon is allowed
+func ValidateSelectStatement(query string) error {
+	words, err := sqlKeywords(query)
+	if err != nil {
+		return err
+	}
+	if len(words) == 0 {
+		return errors.New("query is empty")
+	}
+	if words[0] != "SELECT" && words[0] != "WITH" {
+		return errors.New("query must start with SELECT or WITH")
+	}
+	for _, word := range words {
+		if slices.Contains(forbiddenKeywords, word) {
+			return errors.New("query must not use " + word)
+		}
+	}
+	return nil
+}
+
+// sqlKeywords Returns the upper cased words of the query which are outside of literals, quoted identifiers and
+// comments. It fails when the query holds more than one statement or an unterminated literal or comment
+func sqlKeywords(query string) ([]string, error) {
+	var words []string
+	runes := []rune(query)
+	ended := false
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			continue
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			continue
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			j := i + 2
+			for j+1 < len(runes) && !(runes[j] == '*' && runes[j+1] == '/') {
+				j++
+			}
+			if j+1 >= len(runes) {
+				return nil, errors.New("query has an unterminated comment")
+			}
+			i = j + 1
+			continue
+		}
+
+		if ended {
+			return nil, errors.New("query must be a single statement")
+		}
+		switch {
+		case r == ';':
+			ended = true
+		case r == '\'' || r == '"' || r == '`' || r == '[':
+			closing := r
+			if r == '[' {
+				closing = ']'
+			}
+			j := i + 1
+			for ; j < len(runes); j++ {
+				if runes[j] != closing {
+					continue
+				}
+				// a doubled quote is an escaped quote
+				if closing != ']' && j+1 < len(runes) && runes[j+1] == closing {
+					j++
+					continue
+				}
+				break
+			}
+			if j >= len(runes) {
+				return nil, errors.New("query has an unterminated literal")
+			}
+			i = j
+		case r == '_' || unicode.IsLetter(r):
+			j := i
+			for j < len(runes) && (runes[j] == '_' || unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j])) {
+				j++
+			}
+			words = append(words, strings.ToUpper(string(runes[i:j])))
+			i = j - 1
+		}
+	}
+	return words, nil
+}
@@ -0,0 +1,66 @@
+package helper
+
+import (
+	"fmt"
+	"scheduler/config"
+	"time"
+)
+
+// LoadLocation Loads an IANA timezone. An empty name is treated as UTC
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// SchedulerLocation Returns the configured timezone of the scheduler. It falls back to UTC when the timezone is invalid
+func SchedulerLocation() *time.Location {
+	loc, err := LoadLocation(config.GetConfig().Scheduler.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// JobLocation Returns the timezone a job is scheduled in. Jobs without their own timezone use the one of the scheduler
+func JobLocation(timezone string) *time.Location {
+	if timezone == "" {
+		return SchedulerLocation()
+	}
+	loc, err := LoadLocation(timezone)
+	if err != nil {
+		return SchedulerLocation()
+	}
+	return loc
+}
+
+// ScheduleSpec Returns the cron spec which fires the expression in the given timezone
+func ScheduleSpec(cronExpression string, timezone string) string {
+	if timezone == "" {
+		return cronExpression
+	}
+	return fmt.Sprintf("CRON_TZ=%s %s", timezone, cronExpression)
+}
+
+// ParseStoredTime Parses a timestamp stored in UTC. Columns declared as datetime are read back as RFC3339 by the
+// driver, so that layout is accepted too
+func ParseStoredTime(value string) (time.Time, error) {
+	t, err := time.ParseInLocation(TimeLayout, value, time.UTC)
+	if err == nil {
+		return t, nil
+	}
+	if t, rfcErr := time.Parse(time.RFC3339, value); rfcErr == nil {
+		return t.UTC(), nil
+	}
+	return t, err
+}
+
+// FormatStoredTime Converts a timestamp stored in UTC to RFC3339 in the given timezone. Empty or unparsable values are returned as is
+func FormatStoredTime(value string, loc *time.Location) string {
+	t, err := ParseStoredTime(value)
+	if err != nil {
+		return value
+	}
+	return t.In(loc).Format(time.RFC3339)
+}
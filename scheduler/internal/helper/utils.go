@@ -1,23 +1,22 @@
 package helper
 
 import (
-	"fmt"
 	"github.com/robfig/cron/v3"
+	"math"
+	"scheduler/config"
 	"scheduler/pkg/exception"
 	"strings"
 	"time"
 )
 
-func GenerateDurationClauses(columnName string) map[string]string {
-	return map[string]string{
-		"today":        fmt.Sprintf("date(%s) = date('2022-12-22')", columnName),
-		"yesterday":    fmt.Sprintf("date(%s) = date('2022-12-22', '-1 day')", columnName),
-		"last_7_days":  fmt.Sprintf("date(%s) = date('2022-12-22', '-7 day')", columnName),
-		"last_30_days": fmt.Sprintf("date(%s) = date('2022-12-22', '-1 month')", columnName),
-	}
-}
+// TimeLayout is the format in which timestamps are stored in the database
+const TimeLayout = "2006-01-02 15:04:05"
+
+// DateLayout is the format of the dates accepted by the api
+const DateLayout = "2006-01-02"
 
-func parseCronExpression(expr string) (cron.Schedule, error) {
+// ParseCronExpression Parses the standard 5 field cron expressions and the descriptors like "@every 2m"
+func ParseCronExpression(expr string) (cron.Schedule, error) {
 	// If the expression starts with "@", use descriptor-enabled parser
 	if strings.HasPrefix(expr, "@") {
 		parser := cron.NewParser(
@@ -31,11 +30,45 @@ func parseCronExpression(expr string) (cron.Schedule, error) {
 	return cron.ParseStandard(expr)
 }
 
-func GetNextRun(cronExpression string) (time.Time, error) {
-	schedule, err := parseCronExpression(cronExpression)
+// DefaultMinCronInterval The minimum interval between two fires of a job when the scheduler does not configure one
+const DefaultMinCronInterval = time.Minute
+
+// cronIntervalSamples The number of consecutive fires the shortest interval of a cron expression is measured on
+const cronIntervalSamples = 500
+
+// MinCronInterval Returns how soon a job can fire again after a fire, as configured for the scheduler
+func MinCronInterval() time.Duration {
+	if minInterval := config.GetConfig().Scheduler.MinInterval; minInterval > 0 {
+		return minInterval
+	}
+	return DefaultMinCronInterval
+}
+
+// ShortestCronInterval Returns the shortest interval between two consecutive fires of the schedule after the given
+// time. Cron expressions can fire at uneven intervals, so the intervals of a run of consecutive fires are compared
+func ShortestCronInterval(schedule cron.Schedule, from time.Time) time.Duration {
+	if constant, ok := schedule.(cron.ConstantDelaySchedule); ok {
+		return constant.Delay
+	}
+	shortest := time.Duration(math.MaxInt64)
+	previous := schedule.Next(from)
+	for i := 0; i < cronIntervalSamples && !previous.IsZero(); i++ {
+		next := schedule.Next(previous)
+		if next.IsZero() {
+			break
+		}
+		shortest = min(shortest, next.Sub(previous))
+		previous = next
+	}
+	return shortest
+}
+
+// GetNextRun Returns the next fire time of the cron expression evaluated in the given timezone
+func GetNextRun(cronExpression string, loc *time.Location) (time.Time, error) {
+	schedule, err := ParseCronExpression(cronExpression)
 	if err != nil {
 		return time.Time{}, exception.InvalidCronExpression
 	}
-	nextRun := schedule.Next(time.Now().UTC())
+	nextRun := schedule.Next(time.Now().In(loc))
 	return nextRun, nil
 }
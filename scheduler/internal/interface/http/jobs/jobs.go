@@ -57,7 +57,7 @@ func (h *JobsHTTPHandler) AddAJob(c *fiber.Ctx) error {
 
 	err := req.ValidateSchedulerRequest()
 	if err != nil {
-		return exception.ValidationFailedError
+		return err
 	}
 
 	err = h.app.AddJob(c.Context(), req)
@@ -70,3 +70,217 @@ func (h *JobsHTTPHandler) AddAJob(c *fiber.Ctx) error {
 		ResponseMessage: "OK",
 	})
 }
+
+func (h *JobsHTTPHandler) GetJobRuns(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	var req request.JobRunsRequest
+	if err := c.QueryParser(&req); err != nil {
+		return exception.InvalidRequestBodyError
+	}
+
+	err = req.ValidateJobRunsRequest()
+	if err != nil {
+		return err
+	}
+
+	runs, err := h.app.GetJobRuns(c.Context(), id, req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(response.CommonResponse{
+		ResponseCode:    200,
+		ResponseMessage: "OK",
+		Data:            runs,
+	})
+}
+
+func (h *JobsHTTPHandler) RunJobNow(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return err
+	}
+	wait := c.QueryBool("wait", false)
+
+	run, err := h.app.RunJobNow(c.Context(), id, wait)
+	if err != nil {
+		return err
+	}
+	logger.Log.Info("Job has been triggered manually", zap.String("job_id", idStr), zap.String("run_id", run.RunID))
+	responseCode := fiber.StatusOK
+	if !wait {
+		responseCode = fiber.StatusAccepted
+	}
+	return c.Status(responseCode).JSON(response.CommonResponse{
+		ResponseCode:    responseCode,
+		ResponseMessage: "OK",
+		Data:            run,
+	})
+}
+
+// ReplaceAJob Replaces the whole definition of a job
+func (h *JobsHTTPHandler) ReplaceAJob(c *fiber.Ctx) error {
+	var req request.SchedulerRequest
+	if err := c.BodyParser(&req); err != nil {
+		return exception.InvalidRequestBodyError
+	}
+	return h.updateAJob(c, &req)
+}
+
+// PatchAJob Changes the given fields of a job, keeping the rest of its definition
+func (h *JobsHTTPHandler) PatchAJob(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return err
+	}
+	req, err := h.app.GetJobRequest(c.Context(), id)
+	if err != nil {
+		return err
+	}
+	if err := req.ApplyPatch(c.Body()); err != nil {
+		return exception.InvalidRequestBodyError
+	}
+	return h.updateAJob(c, &req)
+}
+
+func (h *JobsHTTPHandler) updateAJob(c *fiber.Ctx, req *request.SchedulerRequest) error {
+	idStr := c.Params("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return err
+	}
+
+	err = req.ValidateSchedulerRequest()
+	if err != nil {
+		return err
+	}
+
+	err = h.app.UpdateJob(c.Context(), id, *req)
+	if err != nil {
+		return err
+	}
+	logger.Log.Info("Schedule has been updated", zap.String("job_id", idStr))
+	return c.JSON(response.CommonResponse{
+		ResponseCode:    200,
+		ResponseMessage: "OK",
+	})
+}
+
+func (h *JobsHTTPHandler) PauseAJob(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return err
+	}
+	err = h.app.PauseJob(c.Context(), id)
+	if err != nil {
+		return err
+	}
+	logger.Log.Info("Schedule has been paused", zap.String("job_id", idStr))
+	return c.JSON(response.CommonResponse{
+		ResponseCode:    200,
+		ResponseMessage: "OK",
+	})
+}
+
+func (h *JobsHTTPHandler) ResumeAJob(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return err
+	}
+	err = h.app.ResumeJob(c.Context(), id)
+	if err != nil {
+		return err
+	}
+	logger.Log.Info("Schedule has been resumed", zap.String("job_id", idStr))
+	return c.JSON(response.CommonResponse{
+		ResponseCode:    200,
+		ResponseMessage: "OK",
+	})
+}
+
+func (h *JobsHTTPHandler) CancelRun(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return err
+	}
+	runID := c.Params("run_id")
+	err = h.app.CancelRun(c.Context(), id, runID)
+	if err != nil {
+		return err
+	}
+	logger.Log.Info("Run has been cancelled", zap.String("job_id", idStr), zap.String("run_id", runID))
+	return c.JSON(response.CommonResponse{
+		ResponseCode:    200,
+		ResponseMessage: "OK",
+	})
+}
+
+func (h *JobsHTTPHandler) BackfillAJob(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return err
+	}
+
+	var req request.BackfillRequest
+	if err := c.BodyParser(&req); err != nil {
+		return exception.InvalidRequestBodyError
+	}
+
+	err = req.ValidateBackfillRequest()
+	if err != nil {
+		return err
+	}
+
+	backfill, err := h.app.BackfillJob(c.Context(), id, req)
+	if err != nil {
+		return err
+	}
+	logger.Log.Info("Backfill has been started", zap.String("job_id", idStr), zap.String("backfill_id", backfill.ID))
+	return c.Status(fiber.StatusAccepted).JSON(response.CommonResponse{
+		ResponseCode:    fiber.StatusAccepted,
+		ResponseMessage: "OK",
+		Data:            backfill,
+	})
+}
+
+func (h *JobsHTTPHandler) GetBackfill(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return err
+	}
+	backfill, err := h.app.GetBackfill(c.Context(), id, c.Params("backfill_id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(response.CommonResponse{
+		ResponseCode:    200,
+		ResponseMessage: "OK",
+		Data:            backfill,
+	})
+}
+
+func (h *JobsHTTPHandler) CancelBackfill(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return err
+	}
+	backfillID := c.Params("backfill_id")
+	err = h.app.CancelBackfill(c.Context(), id, backfillID)
+	if err != nil {
+		return err
+	}
+	logger.Log.Info("Backfill has been cancelled", zap.String("job_id", idStr), zap.String("backfill_id", backfillID))
+	return c.JSON(response.CommonResponse{
+		ResponseCode:    200,
+		ResponseMessage: "OK",
+	})
+}
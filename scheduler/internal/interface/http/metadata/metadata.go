@@ -2,20 +2,30 @@ package metadata
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"scheduler/internal/app/metadata"
 	"scheduler/internal/interface/response"
 )
 
 type MetadataHTTPHandler struct {
+	app metadata.MetadataApp
 }
 
-func NewMetadataHTTPHandler() *MetadataHTTPHandler {
-	return &MetadataHTTPHandler{}
+func NewMetadataHTTPHandler(app metadata.MetadataApp) *MetadataHTTPHandler {
+	return &MetadataHTTPHandler{app: app}
 }
 
 func (m *MetadataHTTPHandler) GetMetaData(c *fiber.Ctx) error {
 	return c.JSON(response.CommonResponse{
 		ResponseCode:    200,
 		ResponseMessage: "OK",
-		Data:            response.Metrics,
+		Data:            m.app.GetMetadata(c.Context()),
+	})
+}
+
+func (m *MetadataHTTPHandler) GetDataSources(c *fiber.Ctx) error {
+	return c.JSON(response.CommonResponse{
+		ResponseCode:    200,
+		ResponseMessage: "OK",
+		Data:            m.app.GetDataSources(c.Context()),
 	})
 }
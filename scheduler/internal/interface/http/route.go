@@ -3,6 +3,7 @@ package http
 import (
 	"github.com/gofiber/fiber/v2"
 	"scheduler/internal/app/jobs"
+	metadataApp "scheduler/internal/app/metadata"
 	httpJobs "scheduler/internal/interface/http/jobs"
 	"scheduler/internal/interface/http/metadata"
 	"scheduler/internal/repository"
@@ -22,11 +23,25 @@ func RegisterRoute(r *fiber.App) {
 	jobHandler := httpJobs.NewJobHTTPHandler(jobApp)
 	jobAPI.Post("/add", jobHandler.AddAJob)
 	jobAPI.Get("/jobs", jobHandler.GetAllJobs)
+	jobAPI.Put("/job/:id", jobHandler.ReplaceAJob)
+	jobAPI.Patch("/job/:id", jobHandler.PatchAJob)
 	jobAPI.Delete("/job/:id", jobHandler.DeleteAJob)
+	jobAPI.Get("/job/:id/runs", jobHandler.GetJobRuns)
+	jobAPI.Post("/job/:id/runs/:run_id/cancel", jobHandler.CancelRun)
+	jobAPI.Post("/job/:id/run", jobHandler.RunJobNow)
+	jobAPI.Post("/job/:id/pause", jobHandler.PauseAJob)
+	jobAPI.Post("/job/:id/resume", jobHandler.ResumeAJob)
+	jobAPI.Post("/job/:id/backfill", jobHandler.BackfillAJob)
+	jobAPI.Get("/job/:id/backfills/:backfill_id", jobHandler.GetBackfill)
+	jobAPI.Post("/job/:id/backfills/:backfill_id/cancel", jobHandler.CancelBackfill)
 
 	// metadata API
 	metadataAPI := v1.Group("/metadata")
-	metadataHandler := metadata.NewMetadataHTTPHandler()
+	metadataHandler := metadata.NewMetadataHTTPHandler(metadataApp.NewMetadataApp())
 	metadataAPI.Get("/", metadataHandler.GetMetaData)
 
+	// data sources API
+	sourcesAPI := v1.Group("/sources")
+	sourcesAPI.Get("/", metadataHandler.GetDataSources)
+
 }
@@ -1,7 +1,18 @@
 package request
 
 import (
-	"errors"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"scheduler/internal/app/scheduler_strategy"
+	"scheduler/internal/datasource"
+	"scheduler/internal/db/model"
+	"scheduler/internal/helper"
+	"scheduler/pkg/exception"
+	"slices"
+	"strings"
+	"time"
 )
 
 type SchedulerRequest struct {
@@ -12,55 +23,488 @@ type SchedulerRequest struct {
 	DurationFilter string `json:"duration_filter"`
 	DurationOption string `json:"duration_option"`
 	CronSchedule   string `json:"cron_schedule"`
+	// Timezone is the IANA timezone the cron schedule is evaluated in. It defaults to the timezone of the scheduler
+	Timezone string `json:"timezone"`
+	// ConcurrencyPolicy is one of allow, skip or queue. It defaults to allow
+	ConcurrencyPolicy string `json:"concurrency_policy"`
+	// MisfirePolicy is one of ignore, run_once or run_all. It defaults to ignore
+	MisfirePolicy string `json:"misfire_policy"`
+	// MaxRuntime is the duration (e.g. "5m") after which a run is aborted. It defaults to 10 minutes
+	MaxRuntime string `json:"max_runtime"`
+	// RetryPolicy is optional. Without it the result is posted only once
+	RetryPolicy *RetryPolicyRequest `json:"retry_policy"`
+	// Type is one of aggregation or sql. It defaults to aggregation
+	Type string `json:"type"`
+	// Query is the read-only query of a sql job. It can use the named parameters :period_start, :period_end and :now
+	Query string `json:"query"`
+	// Parameters are the values of the parameters the strategy of the duration declares, e.g. the bucket of the
+	// bucketed duration
+	Parameters map[string]interface{} `json:"parameters"`
+	// Measures replace Field and Aggregation when a job computes more than one aggregation
+	Measures []MeasureRequest `json:"measures"`
+	// Filters restrict the aggregated rows. All of them have to match
+	Filters []FilterRequest `json:"filters"`
+	// DataSource is the name of the data source the query runs on. It defaults to the default source
+	DataSource string `json:"data_source"`
+	// WatermarkColumn makes the job incremental, it is a numeric or temporal column which grows with every new row
+	WatermarkColumn string `json:"watermark_column"`
 }
 
-var AllowedTables = map[string][]string{
-	"registration": {"weight"},
+// MeasureRequest An aggregation of a field, posted under its alias. The alias defaults to aggregation_field
+type MeasureRequest struct {
+	Field       string `json:"field"`
+	Aggregation string `json:"aggregation"`
+	Alias       string `json:"alias"`
 }
 
-var AllowedAggregations = map[string]string{
-	"min":   "MIN",
-	"max":   "MAX",
-	"avg":   "AVG",
-	"count": "COUNT",
+// FilterRequest A predicate on a field of the table. Value is a number or a string, a list of two values for between,
+// a list of values for in and left out for is_null and not_null
+type FilterRequest struct {
+	Field    string      `json:"field"`
+	Operator string      `json:"operator"`
+	Value    interface{} `json:"value,omitempty"`
 }
 
-var DurationOptions = []string{"today", "yesterday", "last_7_days", "last_30_days", "recent_week", "daily"}
+type RetryPolicyRequest struct {
+	MaxAttempts       int     `json:"max_attempts"`
+	InitialBackoff    string  `json:"initial_backoff"`
+	Multiplier        float64 `json:"multiplier"`
+	MaxBackoff        string  `json:"max_backoff"`
+	RetryableStatuses []int   `json:"retryable_statuses"`
+}
+
+const (
+	MaxRetryAttempts = 10
+	MaxRuntime       = 24 * time.Hour
+	MaxMeasures      = 20
+	MaxFilters       = 20
+	MaxFilterValues  = 100
+	// SchemaTimeout bounds looking up the table of a job in its data source
+	SchemaTimeout = 5 * time.Second
+)
+
+// aliasPattern Aliases are plain identifiers so they can be used as keys of the posted payload
+var aliasPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+var FilterOperators = []string{
+	model.FilterOperatorEq, model.FilterOperatorNe, model.FilterOperatorGt, model.FilterOperatorGte,
+	model.FilterOperatorLt, model.FilterOperatorLte, model.FilterOperatorBetween, model.FilterOperatorIn,
+	model.FilterOperatorIsNull, model.FilterOperatorNotNull,
+}
+
+var ConcurrencyPolicies = []string{model.ConcurrencyPolicyAllow, model.ConcurrencyPolicySkip, model.ConcurrencyPolicyQueue}
+
+var MisfirePolicies = []string{model.MisfirePolicyIgnore, model.MisfirePolicyRunOnce, model.MisfirePolicyRunAll}
+
+// ApplyPatch Changes the fields of the job which the JSON body sets. A patch of the field or the aggregation without
+// measures drops the measures of the job, which would win over the patched single measure otherwise
+func (sch *SchedulerRequest) ApplyPatch(body []byte) error {
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(body, &fields)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(body, sch)
+	if err != nil {
+		return err
+	}
+	_, field := fields["field"]
+	_, aggregation := fields["aggregation"]
+	if _, measures := fields["measures"]; (field || aggregation) && !measures {
+		sch.Measures = nil
+	}
+	return nil
+}
 
+// ValidateSchedulerRequest Validates the job. Every rule fails with its own error which names the offending field
 func (sch *SchedulerRequest) ValidateSchedulerRequest() error {
-	fields, tableOk := AllowedTables[sch.Table]
-	if !tableOk {
-		return errors.New("invalid Table")
+	var err error
+	switch sch.Type {
+	case "", model.JobTypeAggregation:
+		err = sch.validateAggregation()
+	case model.JobTypeSql:
+		err = sch.validateSql()
+	default:
+		err = fieldError("type", "unknown job type")
+	}
+	if err != nil {
+		return err
 	}
 
-	fieldOk := false
-	for _, f := range fields {
-		if f == sch.Field {
-			fieldOk = true
-			break
-		}
+	if _, err := time.LoadLocation(sch.Timezone); err != nil {
+		return fieldError("timezone", "unknown timezone")
+	}
+
+	err = sch.validateCronSchedule()
+	if err != nil {
+		return err
 	}
 
-	if !fieldOk {
-		return errors.New("invalid field for the table")
+	if sch.ConcurrencyPolicy != "" && !slices.Contains(ConcurrencyPolicies, sch.ConcurrencyPolicy) {
+		return fieldError("concurrency_policy", "unknown concurrency policy")
 	}
 
-	_, aggOk := AllowedAggregations[sch.Aggregation]
-	if !aggOk {
-		return errors.New("invalid aggregation")
+	if sch.MisfirePolicy != "" && !slices.Contains(MisfirePolicies, sch.MisfirePolicy) {
+		return fieldError("misfire_policy", "unknown misfire policy")
 	}
 
-	durOk := false
-	for _, dur := range DurationOptions {
-		if dur == sch.DurationOption {
-			durOk = true
-			break
+	if sch.MaxRuntime != "" {
+		maxRuntime, err := time.ParseDuration(sch.MaxRuntime)
+		if err != nil || maxRuntime <= 0 || maxRuntime > MaxRuntime {
+			return fieldError("max_runtime", "has to be a duration up to "+MaxRuntime.String())
 		}
 	}
 
+	if sch.RetryPolicy != nil {
+		return sch.RetryPolicy.ValidateRetryPolicy()
+	}
+
+	return nil
+}
+
+// validateCronSchedule Validates that the cron expression parses and that it never fires sooner after a previous fire
+// than the minimum interval of the scheduler
+func (sch *SchedulerRequest) validateCronSchedule() error {
+	schedule, err := helper.ParseCronExpression(sch.CronSchedule)
+	if err != nil {
+		return fieldError("cron_schedule", "invalid cron expression")
+	}
+	minInterval := helper.MinCronInterval()
+	interval := helper.ShortestCronInterval(schedule, time.Now().In(helper.JobLocation(sch.Timezone)))
+	if interval < minInterval {
+		return fieldError("cron_schedule", fmt.Sprintf("fires every %s, more often than the minimum interval of %s", interval, minInterval))
+	}
+	return nil
+}
+
+// validateAggregation Validates the table, field, aggregation, duration and the parameters of the strategy of the
+// duration of an aggregation job against the types of the columns of the table
+func (sch *SchedulerRequest) validateAggregation() error {
+	table, err := sch.table()
+	if err != nil {
+		return err
+	}
+
+	err = validateDurationFilter(table, sch.DurationFilter)
+	if err != nil {
+		return err
+	}
+
+	definition, durOk := scheduler_strategy.Lookup(sch.DurationOption)
 	if !durOk {
-		return errors.New("invalid duration")
+		return fieldError("duration_option", "unknown duration")
+	}
+
+	if len(sch.Measures) > 0 {
+		err := sch.validateMeasures(table, definition)
+		if err != nil {
+			return err
+		}
+	} else {
+		err := validateMeasure(table, definition, "field", sch.Field, "aggregation", sch.Aggregation)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = sch.validateParameters(definition)
+	if err != nil {
+		return err
+	}
+	err = validateWatermarkColumn(table, definition, sch.WatermarkColumn)
+	if err != nil {
+		return err
+	}
+	return sch.validateFilters(table)
+}
+
+// table Returns the table of the job as discovered in the schema of its data source
+func (sch *SchedulerRequest) table() (datasource.Table, error) {
+	source, err := datasource.GetDataSource(sch.DataSource)
+	if err != nil {
+		return datasource.Table{}, fieldError("data_source", "unknown data source")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), SchemaTimeout)
+	defer cancel()
+	table, ok, err := source.Table(ctx, sch.Table)
+	if err != nil || !ok {
+		return datasource.Table{}, fieldError("table", "unknown table of the data source")
+	}
+	return table, nil
+}
+
+// validateDurationFilter The window of the duration is computed on the duration filter, so it has to be temporal
+func validateDurationFilter(table datasource.Table, durationFilter string) error {
+	column, ok := table.Column(durationFilter)
+	if !ok {
+		return fieldError("duration_filter", "unknown column of the table")
+	}
+	if column.Kind != datasource.KindTemporal {
+		return fieldError("duration_filter", "column is "+column.Kind+" instead of temporal")
+	}
+	return nil
+}
+
+// validateWatermarkColumn Incremental jobs need a duration which supports them and a watermark column which can be
+// compared in order, so a numeric or a temporal one
+func validateWatermarkColumn(table datasource.Table, definition scheduler_strategy.Definition, watermarkColumn string) error {
+	if watermarkColumn == "" {
+		return nil
+	}
+	if !definition.Incremental {
+		return fieldError("watermark_column", "the duration does not support incremental runs")
+	}
+	column, ok := table.Column(watermarkColumn)
+	if !ok {
+		return fieldError("watermark_column", "unknown column of the table")
+	}
+	if column.Kind != datasource.KindNumeric && column.Kind != datasource.KindTemporal {
+		return fieldError("watermark_column", "column is "+column.Kind+" instead of numeric or temporal")
+	}
+	return nil
+}
+
+// validateMeasure Validates the field and the aggregation of a measure. The aggregations which do arithmetic on the
+// values need a numeric column
+func validateMeasure(table datasource.Table, definition scheduler_strategy.Definition, fieldName string, field string,
+	aggregationName string, aggregation string) error {
+	column, ok := table.Column(field)
+	if !ok {
+		return fieldError(fieldName, "unknown column of the table")
+	}
+	if !slices.Contains(definition.Aggregations, aggregation) {
+		return fieldError(aggregationName, "unknown aggregation for the duration")
+	}
+	if slices.Contains(scheduler_strategy.NumericAggregations, aggregation) && column.Kind != datasource.KindNumeric {
+		return fieldError(fieldName, aggregation+" needs a numeric column but the column is "+column.Kind)
+	}
+	return nil
+}
+
+// validateParameters Validates the values of the parameters the strategy declares. Parameters it does not declare
+// are rejected
+func (sch *SchedulerRequest) validateParameters(definition scheduler_strategy.Definition) error {
+	names := make([]string, 0, len(sch.Parameters))
+	for name := range sch.Parameters {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if _, ok := definition.Parameter(name); !ok {
+			return fieldError("parameters."+name, "unknown parameter of the duration")
+		}
+	}
+	for _, parameter := range definition.Parameters {
+		if !parameter.Valid(sch.Parameters[parameter.Name]) {
+			return fieldError("parameters."+parameter.Name, "invalid value for the duration")
+		}
+	}
+	return nil
+}
+
+// validateMeasures Validates the field and aggregation of every measure and fills in the missing aliases. The aliases
+// have to be unique since they are the keys of the posted payload
+func (sch *SchedulerRequest) validateMeasures(table datasource.Table, definition scheduler_strategy.Definition) error {
+	if len(sch.Measures) > MaxMeasures {
+		return fieldError("measures", fmt.Sprintf("more than %d measures", MaxMeasures))
+	}
+	aliases := make(map[string]bool)
+	for i := range sch.Measures {
+		measure := &sch.Measures[i]
+		name := fmt.Sprintf("measures[%d]", i)
+		err := validateMeasure(table, definition, name+".field", measure.Field, name+".aggregation", measure.Aggregation)
+		if err != nil {
+			return err
+		}
+		if measure.Alias == "" {
+			measure.Alias = measure.Aggregation + "_" + measure.Field
+		}
+		if !aliasPattern.MatchString(measure.Alias) || slices.Contains(definition.Output, measure.Alias) {
+			return fieldError(name+".alias", "has to be an identifier which is not a column of the output")
+		}
+		if aliases[measure.Alias] {
+			return fieldError(name+".alias", "duplicate alias")
+		}
+		aliases[measure.Alias] = true
+	}
+	return nil
+}
+
+// validateFilters Validates the field, operator and value of every filter. Values are only ever bound as parameters,
+// so only their shape is checked
+func (sch *SchedulerRequest) validateFilters(table datasource.Table) error {
+	if len(sch.Filters) > MaxFilters {
+		return fieldError("filters", fmt.Sprintf("more than %d filters", MaxFilters))
+	}
+	for i, filter := range sch.Filters {
+		name := fmt.Sprintf("filters[%d]", i)
+		if _, ok := table.Column(filter.Field); !ok {
+			return fieldError(name+".field", "unknown column of the table")
+		}
+		if !slices.Contains(FilterOperators, filter.Operator) {
+			return fieldError(name+".operator", "unknown filter operator")
+		}
+		if !validFilterValue(filter.Operator, filter.Value) {
+			return fieldError(name+".value", "invalid value for the operator")
+		}
+	}
+	return nil
+}
+
+// validFilterValue Checks that the value has the shape the operator expects
+func validFilterValue(operator string, value interface{}) bool {
+	switch operator {
+	case model.FilterOperatorIsNull, model.FilterOperatorNotNull:
+		return value == nil
+	case model.FilterOperatorBetween, model.FilterOperatorIn:
+		values, ok := value.([]interface{})
+		if !ok || len(values) == 0 || len(values) > MaxFilterValues {
+			return false
+		}
+		if operator == model.FilterOperatorBetween && len(values) != 2 {
+			return false
+		}
+		for _, v := range values {
+			if !scalarFilterValue(v) {
+				return false
+			}
+		}
+		return true
+	default:
+		return scalarFilterValue(value)
+	}
+}
+
+// scalarFilterValue Filter values are the numbers and strings of the decoded JSON
+func scalarFilterValue(value interface{}) bool {
+	switch value.(type) {
+	case float64, string:
+		return true
+	default:
+		return false
+	}
+}
+
+// validateSql Validates the query of a sql job against the statement allowlist. Whether SQLite reports it as
+// read-only can only be checked against the database
+func (sch *SchedulerRequest) validateSql() error {
+	definition, ok := scheduler_strategy.Lookup(sch.DurationOption)
+	if !ok || definition.SqlPeriod == nil {
+		return fieldError("duration_option", "unknown duration for sql jobs")
+	}
+	if _, err := datasource.GetDataSource(sch.DataSource); err != nil {
+		return fieldError("data_source", "unknown data source")
+	}
+	if sch.WatermarkColumn != "" {
+		return fieldError("watermark_column", "sql jobs can not be incremental")
+	}
+	if err := helper.ValidateSelectStatement(sch.Query); err != nil {
+		return fieldError("query", err.Error())
+	}
+	return nil
+}
+
+// ValidateRetryPolicy Validates the retry policy. Fields which are left empty are filled with the defaults of the scheduler
+func (r *RetryPolicyRequest) ValidateRetryPolicy() error {
+	if r.MaxAttempts < 0 || r.MaxAttempts > MaxRetryAttempts {
+		return fieldError("retry_policy.max_attempts", fmt.Sprintf("has to be between 0 and %d", MaxRetryAttempts))
 	}
+	if !validBackoff(r.InitialBackoff) {
+		return fieldError("retry_policy.initial_backoff", "has to be a positive duration")
+	}
+	if !validBackoff(r.MaxBackoff) {
+		return fieldError("retry_policy.max_backoff", "has to be a positive duration")
+	}
+	if r.Multiplier != 0 && r.Multiplier < 1 {
+		return fieldError("retry_policy.multiplier", "can not be less than 1")
+	}
+	for _, status := range r.RetryableStatuses {
+		if status < 100 || status > 599 {
+			return fieldError("retry_policy.retryable_statuses", fmt.Sprintf("%d is not an http status", status))
+		}
+	}
+	return nil
+}
+
+// fieldError Returns the validation error of a field of the request
+func fieldError(field string, message string) error {
+	return exception.NewValidationError(field, message)
+}
 
+func validBackoff(backoff string) bool {
+	if backoff == "" {
+		return true
+	}
+	duration, err := time.ParseDuration(backoff)
+	return err == nil && duration > 0
+}
+
+type JobRunsRequest struct {
+	Page     int    `query:"page"`
+	PageSize int    `query:"page_size"`
+	Status   string `query:"status"`
+}
+
+const (
+	DefaultRunsPageSize = 20
+	MaxRunsPageSize     = 100
+)
+
+var RunStatuses = []string{model.RunStatusRunning, model.RunStatusSucceeded, model.RunStatusFailed, model.RunStatusSkipped,
+	model.RunStatusCancelled, model.RunStatusTimedOut, model.RunStatusInterrupted}
+
+// ValidateJobRunsRequest Validates the paging and the status filter. Missing paging values are set to the defaults
+func (r *JobRunsRequest) ValidateJobRunsRequest() error {
+	if r.Page == 0 {
+		r.Page = 1
+	}
+	if r.PageSize == 0 {
+		r.PageSize = DefaultRunsPageSize
+	}
+	if r.Page < 0 {
+		return fieldError("page", "has to be a positive number")
+	}
+	if r.PageSize < 0 || r.PageSize > MaxRunsPageSize {
+		return fieldError("page_size", fmt.Sprintf("has to be between 1 and %d", MaxRunsPageSize))
+	}
+	for _, status := range r.Statuses() {
+		if !slices.Contains(RunStatuses, status) {
+			return fieldError("status", "unknown run status "+status)
+		}
+	}
+	return nil
+}
+
+// Statuses Returns the comma separated status filter as a list
+func (r *JobRunsRequest) Statuses() []string {
+	if r.Status == "" {
+		return nil
+	}
+	var statuses []string
+	for _, status := range strings.Split(r.Status, ",") {
+		statuses = append(statuses, strings.TrimSpace(status))
+	}
+	return statuses
+}
+
+// BackfillRequest The dates are formatted as 2006-01-02 and both are included in the backfill
+type BackfillRequest struct {
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+}
+
+// ValidateBackfillRequest Validates the date range of the backfill
+func (r *BackfillRequest) ValidateBackfillRequest() error {
+	start, err := time.Parse(helper.DateLayout, r.StartDate)
+	if err != nil {
+		return fieldError("start_date", "has to be a date formatted as "+helper.DateLayout)
+	}
+	end, err := time.Parse(helper.DateLayout, r.EndDate)
+	if err != nil {
+		return fieldError("end_date", "has to be a date formatted as "+helper.DateLayout)
+	}
+	if end.Before(start) {
+		return fieldError("end_date", "is before the start date")
+	}
 	return nil
 }
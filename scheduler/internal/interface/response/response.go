@@ -1,6 +1,8 @@
 package response
 
 import (
+	"scheduler/internal/app/scheduler_strategy"
+	"scheduler/internal/datasource"
 	"scheduler/internal/interface/request"
 	"scheduler/pkg/exception"
 )
@@ -17,35 +19,142 @@ type Jobs struct {
 	Name           string `json:"name"`
 	CronExpression string `json:"cron_expression"`
 	Enabled        bool   `json:"enabled"`
+	Status         string `json:"status"`
 	Table          string `json:"table"`
 	Field          string `json:"field"`
 	Aggregation    string `json:"aggregation"`
 	Duration       string `json:"duration"`
 	DurationFilter string `json:"duration_filter"`
-	CreatedAt      string `json:"created_at"`
-	LastRun        string `json:"last_run"`
-	NextRun        string `json:"next_run"`
+	// Timezone is the zone the schedule is evaluated in. The timestamps are RFC3339 in this zone
+	Timezone          string      `json:"timezone"`
+	CreatedAt         string      `json:"created_at"`
+	LastRun           string      `json:"last_run"`
+	NextRun           string      `json:"next_run"`
+	ConcurrencyPolicy string      `json:"concurrency_policy"`
+	MisfirePolicy     string      `json:"misfire_policy"`
+	MaxRuntime        string      `json:"max_runtime,omitempty"`
+	RetryPolicy       RetryPolicy `json:"retry_policy"`
+	Type              string      `json:"type"`
+	Query             string      `json:"query,omitempty"`
+	// Parameters are the values of the parameters the strategy of the duration declares
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	// Measures is empty for jobs which compute the single aggregation of Field
+	Measures []request.MeasureRequest `json:"measures,omitempty"`
+	Filters  []request.FilterRequest  `json:"filters,omitempty"`
+	// DataSource is the name of the data source the query runs on
+	DataSource string `json:"data_source"`
+	// Watermark is the highest value of the watermark column of an incremental job which its posted results cover
+	WatermarkColumn string `json:"watermark_column,omitempty"`
+	Watermark       string `json:"watermark,omitempty"`
+}
+
+type RetryPolicy struct {
+	MaxAttempts       int     `json:"max_attempts"`
+	InitialBackoff    string  `json:"initial_backoff,omitempty"`
+	Multiplier        float64 `json:"multiplier,omitempty"`
+	MaxBackoff        string  `json:"max_backoff,omitempty"`
+	RetryableStatuses []int   `json:"retryable_statuses,omitempty"`
+}
+
+type JobRun struct {
+	ID          string `json:"id"`
+	JobID       uint   `json:"job_id"`
+	Trigger     string `json:"trigger"`
+	ScheduledAt string `json:"scheduled_at"`
+	StartedAt   string `json:"started_at"`
+	FinishedAt  string `json:"finished_at"`
+	Status      string `json:"status"`
+	RowCount    int    `json:"row_count"`
+	Error       string `json:"error,omitempty"`
+	HttpStatus  int    `json:"http_status"`
+	Attempts    int    `json:"attempts"`
+	BackfillID  string `json:"backfill_id,omitempty"`
+}
+
+type JobRuns struct {
+	Runs     []JobRun `json:"runs"`
+	Page     int      `json:"page"`
+	PageSize int      `json:"page_size"`
+	Total    int64    `json:"total"`
+}
+
+type TriggeredRun struct {
+	RunID        string `json:"run_id"`
+	Status       string `json:"status"`
+	Query        string `json:"query,omitempty"`
+	RowCount     int    `json:"row_count"`
+	Error        string `json:"error,omitempty"`
+	HttpStatus   int    `json:"http_status,omitempty"`
+	SinkResponse string `json:"sink_response,omitempty"`
+}
+
+type Backfill struct {
+	ID               string `json:"id"`
+	JobID            uint   `json:"job_id"`
+	StartDate        string `json:"start_date"`
+	EndDate          string `json:"end_date"`
+	Status           string `json:"status"`
+	TotalPeriods     int    `json:"total_periods"`
+	CompletedPeriods int    `json:"completed_periods"`
+	FailedPeriods    int    `json:"failed_periods"`
+	CreatedAt        string `json:"created_at"`
+	FinishedAt       string `json:"finished_at"`
+	Error            string `json:"error,omitempty"`
 }
 
 type MetricConfig struct {
-	Table          string   `json:"table"`
-	Fields         []string `json:"fields"`
-	Aggregations   []string `json:"aggregations"`
-	DurationFilter []string `json:"duration_filter"`
-	Durations      []string `json:"durations"`
+	DataSource      string   `json:"data_source"`
+	Table           string   `json:"table"`
+	Fields          []string `json:"fields"`
+	Columns         []Column `json:"columns"`
+	Aggregations    []string `json:"aggregations"`
+	FilterOperators []string `json:"filter_operators"`
+	DurationFilter  []string `json:"duration_filter"`
+	Durations       []string `json:"durations"`
 }
 
+// MetadataResponse The tables the jobs can aggregate and the durations they can use, which are the same for every table
 type MetadataResponse struct {
 	Metrics   []MetricConfig `json:"metrics"`
 	Durations []string       `json:"durations"`
+	// Strategies describe the durations, their parameters and the columns they post next to the measures
+	Strategies []scheduler_strategy.Definition `json:"strategies"`
+}
+
+// DataSource The health and the connection pool of a data source
+type DataSource struct {
+	Name               string `json:"name"`
+	Driver             string `json:"driver"`
+	Healthy            bool   `json:"healthy"`
+	Error              string `json:"error,omitempty"`
+	MaxOpenConnections int    `json:"max_open_connections"`
+	OpenConnections    int    `json:"open_connections"`
+	InUse              int    `json:"in_use"`
+	Idle               int    `json:"idle"`
+}
+
+// Column A column of a table with its declared type and the kind it is classified as
+type Column struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Kind string `json:"kind"`
 }
 
-var Metrics = []MetricConfig{
-	{
-		Table:          "registration",
-		Fields:         []string{"weight"},
-		Aggregations:   []string{"min", "max", "avg", "count"},
-		DurationFilter: []string{"timestamp"},
-		Durations:      request.DurationOptions,
-	},
+// NewMetricConfig Describes what the jobs can aggregate on a discovered table of a data source. Every column is a
+// field, the temporal columns are the duration filters
+func NewMetricConfig(source string, table datasource.Table) MetricConfig {
+	columns := make([]Column, 0, len(table.Columns))
+	for _, column := range table.Columns {
+		columns = append(columns, Column{Name: column.Name, Type: column.Type, Kind: column.Kind})
+	}
+	return MetricConfig{
+		DataSource:      source,
+		Table:           table.Name,
+		Fields:          table.ColumnNames(),
+		Columns:         columns,
+		Aggregations:    scheduler_strategy.AggregationNames,
+		FilterOperators: request.FilterOperators,
+		DurationFilter:  table.ColumnNames(datasource.KindTemporal),
+		Durations:       scheduler_strategy.DurationNames(),
+	}
 }
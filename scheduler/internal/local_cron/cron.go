@@ -1,31 +1,36 @@
 package local_cron
 
 import (
+	"context"
 	"github.com/robfig/cron/v3"
+	"scheduler/internal/helper"
 	"sync"
+	"time"
 )
 
 type LocalCron struct {
 	Cron   *cron.Cron
 	JobMap map[uint]cron.EntryID
+	mu     sync.Mutex
 }
 
 var m sync.Mutex
 var localCron LocalCron
 
-func InitCron() {
+// InitCron Creating the cron which evaluates the schedules in the given timezone
+func InitCron(loc *time.Location) {
 	m.Lock()
 	defer m.Unlock()
 
 	if localCron.Cron == nil {
-		localCron.Cron = cron.New()
+		localCron.Cron = cron.New(cron.WithLocation(loc))
 		localCron.JobMap = make(map[uint]cron.EntryID)
 	}
 }
 
 func GetCron() *LocalCron {
 	if localCron.Cron == nil {
-		InitCron()
+		InitCron(helper.SchedulerLocation())
 		StartCron()
 	}
 	return &localCron
@@ -35,6 +40,49 @@ func StartCron() {
 	localCron.Cron.Start()
 }
 
-func StopCron() {
-	localCron.Cron.Stop()
+// StopCron Stops the cron from firing. The returned context is done once the jobs started by the cron are finished
+func StopCron() context.Context {
+	return localCron.Cron.Stop()
+}
+
+// ScheduleJob Registers the function of a job, replacing the entry which is already registered for the job.
+// The swap is atomic: the new entry only fires once it is registered and the replaced entry never fires after the swap
+func (l *LocalCron) ScheduleJob(jobID uint, spec string, fn func()) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var entryID cron.EntryID
+	newEntryID, err := l.Cron.AddFunc(spec, func() {
+		l.mu.Lock()
+		current := l.JobMap[jobID] == entryID
+		l.mu.Unlock()
+		if current {
+			fn()
+		}
+	})
+	if err != nil {
+		return err
+	}
+	entryID = newEntryID
+
+	oldEntryID, scheduled := l.JobMap[jobID]
+	l.JobMap[jobID] = newEntryID
+	if scheduled {
+		l.Cron.Remove(oldEntryID)
+	}
+	return nil
+}
+
+// UnscheduleJob Removes the entry of a job. It returns false when the job was not scheduled
+func (l *LocalCron) UnscheduleJob(jobID uint) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entryID, scheduled := l.JobMap[jobID]
+	if !scheduled {
+		return false
+	}
+	l.Cron.Remove(entryID)
+	delete(l.JobMap, jobID)
+	return true
 }
@@ -3,12 +3,24 @@ package observer
 import (
 	"context"
 	"scheduler/internal/db/model"
+	"time"
 )
 
+// DeliveryAttempt is a single try to post the result of a run to the api
+type DeliveryAttempt struct {
+	Number     int
+	HttpStatus int
+	Err        error
+	// Backoff is the wait before the next attempt. It is zero when no further attempt follows
+	Backoff time.Duration
+}
+
 type JobEventListener interface {
-	OnJobStarted(ctx context.Context, job model.CronJob)
-	OnJobCompleted(ctx context.Context, job model.CronJob, result any)
-	OnJobFailed(ctx context.Context, job model.CronJob, err error)
+	OnJobStarted(ctx context.Context, job model.CronJob, run model.JobRun)
+	OnDeliveryAttempt(ctx context.Context, job model.CronJob, run model.JobRun, attempt DeliveryAttempt)
+	OnJobCompleted(ctx context.Context, job model.CronJob, run model.JobRun, result any)
+	OnJobFailed(ctx context.Context, job model.CronJob, run model.JobRun, err error)
+	OnJobSkipped(ctx context.Context, job model.CronJob, run model.JobRun)
 }
 
 type JobEventDispatcher struct {
@@ -23,20 +35,32 @@ func (d *JobEventDispatcher) RegisterListener(listener JobEventListener) {
 	d.listeners = append(d.listeners, listener)
 }
 
-func (d *JobEventDispatcher) EmitStarted(ctx context.Context, job model.CronJob) {
+func (d *JobEventDispatcher) EmitStarted(ctx context.Context, job model.CronJob, run model.JobRun) {
+	for _, l := range d.listeners {
+		l.OnJobStarted(ctx, job, run)
+	}
+}
+
+func (d *JobEventDispatcher) EmitDeliveryAttempt(ctx context.Context, job model.CronJob, run model.JobRun, attempt DeliveryAttempt) {
+	for _, l := range d.listeners {
+		l.OnDeliveryAttempt(ctx, job, run, attempt)
+	}
+}
+
+func (d *JobEventDispatcher) EmitCompleted(ctx context.Context, job model.CronJob, run model.JobRun, result any) {
 	for _, l := range d.listeners {
-		l.OnJobStarted(ctx, job)
+		l.OnJobCompleted(ctx, job, run, result)
 	}
 }
 
-func (d *JobEventDispatcher) EmitCompleted(ctx context.Context, job model.CronJob, result any) {
+func (d *JobEventDispatcher) EmitFailed(ctx context.Context, job model.CronJob, run model.JobRun, err error) {
 	for _, l := range d.listeners {
-		l.OnJobCompleted(ctx, job, result)
+		l.OnJobFailed(ctx, job, run, err)
 	}
 }
 
-func (d *JobEventDispatcher) EmitFailed(ctx context.Context, job model.CronJob, err error) {
+func (d *JobEventDispatcher) EmitSkipped(ctx context.Context, job model.CronJob, run model.JobRun) {
 	for _, l := range d.listeners {
-		l.OnJobFailed(ctx, job, err)
+		l.OnJobSkipped(ctx, job, run)
 	}
 }
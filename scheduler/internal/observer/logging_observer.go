@@ -9,14 +9,29 @@ import (
 
 type LoggingListener struct{}
 
-func (l *LoggingListener) OnJobStarted(ctx context.Context, job model.CronJob) {
-	logger.Log.Info("Job started", zap.String("job_name", job.Name))
+func (l *LoggingListener) OnJobStarted(ctx context.Context, job model.CronJob, run model.JobRun) {
+	logger.Log.Info("Job started", zap.String("job_name", job.Name), zap.String("run_id", run.ID))
 }
 
-func (l *LoggingListener) OnJobCompleted(ctx context.Context, job model.CronJob, result any) {
-	logger.Log.Info("Job completed", zap.String("job_name", job.Name))
+func (l *LoggingListener) OnDeliveryAttempt(ctx context.Context, job model.CronJob, run model.JobRun, attempt DeliveryAttempt) {
+	if attempt.Err == nil {
+		logger.Log.Debug("Result delivered", zap.String("job_name", job.Name), zap.String("run_id", run.ID),
+			zap.Int("attempt", attempt.Number), zap.Int("http_status", attempt.HttpStatus))
+		return
+	}
+	logger.Log.Warn("Result delivery failed", zap.String("job_name", job.Name), zap.String("run_id", run.ID),
+		zap.Int("attempt", attempt.Number), zap.Int("http_status", attempt.HttpStatus),
+		zap.Duration("retry_in", attempt.Backoff), zap.Error(attempt.Err))
 }
 
-func (l *LoggingListener) OnJobFailed(ctx context.Context, job model.CronJob, err error) {
-	logger.Log.Error("Job failed", zap.String("job_name", job.Name), zap.Error(err))
+func (l *LoggingListener) OnJobCompleted(ctx context.Context, job model.CronJob, run model.JobRun, result any) {
+	logger.Log.Info("Job completed", zap.String("job_name", job.Name), zap.String("run_id", run.ID))
+}
+
+func (l *LoggingListener) OnJobFailed(ctx context.Context, job model.CronJob, run model.JobRun, err error) {
+	logger.Log.Error("Job failed", zap.String("job_name", job.Name), zap.String("run_id", run.ID), zap.Error(err))
+}
+
+func (l *LoggingListener) OnJobSkipped(ctx context.Context, job model.CronJob, run model.JobRun) {
+	logger.Log.Warn("Job skipped", zap.String("job_name", job.Name), zap.String("run_id", run.ID), zap.String("reason", run.Error))
 }
@@ -0,0 +1,55 @@
+package observer
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"scheduler/internal/db/model"
+	"scheduler/internal/logger"
+	"scheduler/internal/repository"
+	"time"
+)
+
+// RunHistoryListener persists every run of a job so that the outcome can be inspected later
+type RunHistoryListener struct {
+	repo repository.JobRunRepository
+}
+
+func NewRunHistoryListener(repo repository.JobRunRepository) *RunHistoryListener {
+	return &RunHistoryListener{repo: repo}
+}
+
+func (l *RunHistoryListener) OnJobStarted(ctx context.Context, job model.CronJob, run model.JobRun) {
+	l.add(job, run)
+}
+
+func (l *RunHistoryListener) OnJobSkipped(ctx context.Context, job model.CronJob, run model.JobRun) {
+	l.add(job, run)
+}
+
+func (l *RunHistoryListener) add(job model.CronJob, run model.JobRun) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := l.repo.AddRun(dbCtx, &run); err != nil {
+		logger.Log.Error("Unable to store the job run", zap.String("job_name", job.Name), zap.String("run_id", run.ID), zap.Error(err))
+	}
+}
+
+func (l *RunHistoryListener) OnDeliveryAttempt(ctx context.Context, job model.CronJob, run model.JobRun, attempt DeliveryAttempt) {
+	l.update(job, run)
+}
+
+func (l *RunHistoryListener) OnJobCompleted(ctx context.Context, job model.CronJob, run model.JobRun, result any) {
+	l.update(job, run)
+}
+
+func (l *RunHistoryListener) OnJobFailed(ctx context.Context, job model.CronJob, run model.JobRun, err error) {
+	l.update(job, run)
+}
+
+func (l *RunHistoryListener) update(job model.CronJob, run model.JobRun) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := l.repo.UpdateRun(dbCtx, run); err != nil {
+		logger.Log.Error("Unable to update the job run", zap.String("job_name", job.Name), zap.String("run_id", run.ID), zap.Error(err))
+	}
+}
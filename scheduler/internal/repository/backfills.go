@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"scheduler/internal/db/model"
+)
+
+// BackfillRepository Function declaration for storing the backfills of the jobs
+type BackfillRepository interface {
+	AddBackfill(context.Context, *model.Backfill) error
+	UpdateBackfill(context.Context, model.Backfill) error
+	GetBackfill(context.Context, string) (model.Backfill, error)
+	InterruptRunningBackfills(context.Context) (int64, error)
+}
+
+type BackfillRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+// NewBackfillRepository Function to inject the database object
+func NewBackfillRepository(db *gorm.DB) BackfillRepository {
+	return &BackfillRepositoryImpl{DB: db}
+}
+
+// AddBackfill Add a backfill in the database
+func (b *BackfillRepositoryImpl) AddBackfill(ctx context.Context, backfill *model.Backfill) error {
+	err := b.DB.WithContext(ctx).Create(backfill).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpdateBackfill Overwrite the progress of the stored backfill with the given state
+func (b *BackfillRepositoryImpl) UpdateBackfill(ctx context.Context, backfill model.Backfill) error {
+	err := b.DB.WithContext(ctx).Model(&model.Backfill{}).Where("id = ?", backfill.ID).Updates(map[string]interface{}{
+		"status":            backfill.Status,
+		"completed_periods": backfill.CompletedPeriods,
+		"failed_periods":    backfill.FailedPeriods,
+		"finished_at":       backfill.FinishedAt,
+		"error":             backfill.Error,
+	}).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetBackfill Get a backfill from its id
+func (b *BackfillRepositoryImpl) GetBackfill(ctx context.Context, backfillID string) (model.Backfill, error) {
+	var backfill model.Backfill
+	err := b.DB.WithContext(ctx).Where("id = ?", backfillID).First(&backfill).Error
+	if err != nil {
+		return backfill, err
+	}
+	return backfill, nil
+}
+
+// InterruptRunningBackfills Mark the backfills which are still running as interrupted. It is used on boot, when no backfill of the process has started yet
+func (b *BackfillRepositoryImpl) InterruptRunningBackfills(ctx context.Context) (int64, error) {
+	result := b.DB.WithContext(ctx).Model(&model.Backfill{}).Where("status = ?", model.BackfillStatusRunning).Updates(map[string]interface{}{
+		"status": model.BackfillStatusInterrupted,
+		"error":  "backfill was interrupted by a shutdown",
+	})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/mattn/go-sqlite3"
+	"scheduler/internal/datasource"
+)
+
+// DataSourceRepository Function declaration for running the queries of the jobs on their data source. An empty
+// source is the default source
+type DataSourceRepository interface {
+	ExecuteRawQuery(context.Context, string, string, ...interface{}) ([]map[string]interface{}, error)
+	StreamRawQuery(context.Context, string, func([]interface{}) error, string, ...interface{}) error
+	IsReadOnlyQuery(context.Context, string, string) (bool, error)
+}
+
+type DataSourceRepositoryImpl struct{}
+
+// NewDataSourceRepository The data sources are looked up by name on every query
+func NewDataSourceRepository() DataSourceRepository {
+	return &DataSourceRepositoryImpl{}
+}
+
+// ExecuteRawQuery Execute raw query directly on the source with the given parameters. The query is interrupted when the context is done
+func (d *DataSourceRepositoryImpl) ExecuteRawQuery(ctx context.Context, source string, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	dataSource, err := datasource.GetDataSource(source)
+	if err != nil {
+		return nil, err
+	}
+	var rows []map[string]interface{}
+	err = dataSource.DB.WithContext(ctx).Raw(query, args...).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// StreamRawQuery Execute raw query directly on the source and hand every row to the function as it is read, instead of
+// loading the whole result. The query stops at the first error of the function
+func (d *DataSourceRepositoryImpl) StreamRawQuery(ctx context.Context, source string, each func([]interface{}) error, query string, args ...interface{}) error {
+	dataSource, err := datasource.GetDataSource(source)
+	if err != nil {
+		return err
+	}
+	rows, err := dataSource.DB.WithContext(ctx).Raw(query, args...).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	for rows.Next() {
+		err = rows.Scan(pointers...)
+		if err != nil {
+			return err
+		}
+		err = each(values)
+		if err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// IsReadOnlyQuery Prepares the query on the source without running it and reports whether SQLite considers it read-only.
+// The query is not valid when it can not be prepared
+func (d *DataSourceRepositoryImpl) IsReadOnlyQuery(ctx context.Context, source string, query string) (bool, error) {
+	dataSource, err := datasource.GetDataSource(source)
+	if err != nil {
+		return false, err
+	}
+	sqlDB, err := dataSource.DB.DB()
+	if err != nil {
+		return false, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	readOnly := false
+	err = conn.Raw(func(driverConn interface{}) error {
+		sqliteConn, ok := driverConn.(*sqlite3.SQLiteConn)
+		if !ok {
+			return errors.New("connection is not a sqlite connection")
+		}
+		stmt, err := sqliteConn.Prepare(query)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		readOnly = stmt.(*sqlite3.SQLiteStmt).Readonly()
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return readOnly, nil
+}
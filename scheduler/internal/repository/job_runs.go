@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"scheduler/internal/db/model"
+)
+
+// JobRunFilter Filter and paging options for listing the runs of a job
+type JobRunFilter struct {
+	Statuses []string
+	Limit    int
+	Offset   int
+}
+
+// JobRunRepository Function declaration for storing the run history of the jobs
+type JobRunRepository interface {
+	AddRun(context.Context, *model.JobRun) error
+	UpdateRun(context.Context, model.JobRun) error
+	GetRunsForJob(context.Context, uint, JobRunFilter) ([]model.JobRun, int64, error)
+	GetRun(context.Context, string) (model.JobRun, error)
+	InterruptRunningRuns(context.Context) (int64, error)
+	GetRunsToResume(context.Context) ([]model.JobRun, error)
+	SetResumedBy(context.Context, string, string) error
+}
+
+type JobRunRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+// NewJobRunRepository Function to inject the database object
+func NewJobRunRepository(db *gorm.DB) JobRunRepository {
+	return &JobRunRepositoryImpl{DB: db}
+}
+
+// AddRun Add a run in the database
+func (j *JobRunRepositoryImpl) AddRun(ctx context.Context, run *model.JobRun) error {
+	err := j.DB.WithContext(ctx).Create(run).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpdateRun Overwrite the stored run with the given state
+func (j *JobRunRepositoryImpl) UpdateRun(ctx context.Context, run model.JobRun) error {
+	err := j.DB.WithContext(ctx).Model(&model.JobRun{}).Where("id = ?", run.ID).Updates(map[string]interface{}{
+		"finished_at": run.FinishedAt,
+		"status":      run.Status,
+		"row_count":   run.RowCount,
+		"error":       run.Error,
+		"http_status": run.HttpStatus,
+		"attempts":    run.Attempts,
+	}).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetRunsForJob Get the runs of a job, newest first, along with the total number of matching runs
+func (j *JobRunRepositoryImpl) GetRunsForJob(ctx context.Context, jobID uint, filter JobRunFilter) ([]model.JobRun, int64, error) {
+	var runs []model.JobRun
+	var total int64
+	query := j.DB.WithContext(ctx).Model(&model.JobRun{}).Where("job_id = ?", jobID)
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	query = query.Session(&gorm.Session{})
+	err := query.Count(&total).Error
+	if err != nil {
+		return runs, 0, err
+	}
+	err = query.Order("started_at DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&runs).Error
+	if err != nil {
+		return runs, 0, err
+	}
+	return runs, total, nil
+}
+
+// GetRun Get a run from its id
+func (j *JobRunRepositoryImpl) GetRun(ctx context.Context, runID string) (model.JobRun, error) {
+	var run model.JobRun
+	err := j.DB.WithContext(ctx).Where("id = ?", runID).First(&run).Error
+	if err != nil {
+		return run, err
+	}
+	return run, nil
+}
+
+// InterruptRunningRuns Mark the runs which are still running as interrupted. It is used on boot, when no run of the process has started yet
+func (j *JobRunRepositoryImpl) InterruptRunningRuns(ctx context.Context) (int64, error) {
+	result := j.DB.WithContext(ctx).Model(&model.JobRun{}).Where("status = ?", model.RunStatusRunning).Updates(map[string]interface{}{
+		"status": model.RunStatusInterrupted,
+		"error":  "run was interrupted by a shutdown",
+	})
+	return result.RowsAffected, result.Error
+}
+
+// GetRunsToResume Get the interrupted runs which were not resumed yet, oldest first. Runs of backfills are not resumed
+func (j *JobRunRepositoryImpl) GetRunsToResume(ctx context.Context) ([]model.JobRun, error) {
+	var runs []model.JobRun
+	err := j.DB.WithContext(ctx).
+		Where("status = ? AND (resumed_by IS NULL OR resumed_by = '')", model.RunStatusInterrupted).
+		Where("trigger <> ?", model.RunTriggerBackfill).
+		Order("scheduled_at").Find(&runs).Error
+	if err != nil {
+		return runs, err
+	}
+	return runs, nil
+}
+
+// SetResumedBy Link an interrupted run to the run which resumes it
+func (j *JobRunRepositoryImpl) SetResumedBy(ctx context.Context, runID string, resumedBy string) error {
+	err := j.DB.WithContext(ctx).Model(&model.JobRun{}).Where("id = ?", runID).Update("resumed_by", resumedBy).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -9,12 +9,11 @@ import (
 
 // JobRepository Function declaration for running the query in database
 type JobRepository interface {
-	GetAllEnabledJobs(context.Context) ([]model.CronJob, error)
+	GetAllActiveJobs(context.Context) ([]model.CronJob, error)
 	UpdateJob(context.Context, model.CronJob, map[string]interface{}) error
 	GetAllJobs(context.Context) ([]model.CronJob, error)
 	AddAJob(context.Context, *model.CronJob) error
 	GetAJobFromID(context.Context, int) (model.CronJob, error)
-	ExecuteRawQuery(string) []map[string]interface{}
 }
 
 type JobRepositoryImpl struct {
@@ -26,10 +25,10 @@ func NewJobRepository(db *gorm.DB) JobRepository {
 	return &JobRepositoryImpl{DB: db}
 }
 
-// GetAllEnabledJobs Get all the enbaled jobs from the database
-func (j *JobRepositoryImpl) GetAllEnabledJobs(ctx context.Context) ([]model.CronJob, error) {
+// GetAllActiveJobs Get all the active jobs from the database
+func (j *JobRepositoryImpl) GetAllActiveJobs(ctx context.Context) ([]model.CronJob, error) {
 	var jobs []model.CronJob
-	err := j.DB.WithContext(ctx).Where("enabled = ?", true).Find(&jobs).Error
+	err := j.DB.WithContext(ctx).Where("status = ?", model.JobStatusActive).Find(&jobs).Error
 	if err != nil {
 		return jobs, err
 	}
@@ -76,13 +75,3 @@ func (j *JobRepositoryImpl) GetAJobFromID(ctx context.Context, jobID int) (model
 	}
 	return job, nil
 }
-
-// ExecuteRawQuery Execute raw query directly
-func (j *JobRepositoryImpl) ExecuteRawQuery(query string) []map[string]interface{} {
-	var rows []map[string]interface{}
-	err := j.DB.Raw(query).Scan(&rows).Error
-	if err != nil {
-		return nil
-	}
-	return rows
-}
@@ -3,12 +3,22 @@ package repository
 import "scheduler/internal/db/sqlite"
 
 type Repository struct {
-	Job JobRepository
+	Job      JobRepository
+	JobRun   JobRunRepository
+	Backfill BackfillRepository
+	// Watermark keeps how far the incremental jobs got
+	Watermark WatermarkRepository
+	// DataSource runs the queries of the jobs on the data source they pick
+	DataSource DataSourceRepository
 }
 
 func NewRepository() *Repository {
 	db := sqlite.GetSqliteDB()
 	return &Repository{
-		Job: NewJobRepository(db),
+		Job:        NewJobRepository(db),
+		JobRun:     NewJobRunRepository(db),
+		Backfill:   NewBackfillRepository(db),
+		Watermark:  NewWatermarkRepository(db),
+		DataSource: NewDataSourceRepository(),
 	}
 }
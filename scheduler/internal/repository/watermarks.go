@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"scheduler/internal/db/model"
+)
+
+// WatermarkRepository Function declaration for storing the watermarks of the incremental jobs
+type WatermarkRepository interface {
+	GetWatermark(context.Context, uint) (model.Watermark, bool, error)
+	GetAllWatermarks(context.Context) (map[uint]model.Watermark, error)
+	SaveWatermark(context.Context, model.Watermark) error
+	DeleteWatermark(context.Context, uint) error
+}
+
+type WatermarkRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+// NewWatermarkRepository Function to inject the database object
+func NewWatermarkRepository(db *gorm.DB) WatermarkRepository {
+	return &WatermarkRepositoryImpl{DB: db}
+}
+
+// GetWatermark Get the watermark of a job. Jobs which did not deliver an incremental result yet have none
+func (w *WatermarkRepositoryImpl) GetWatermark(ctx context.Context, jobID uint) (model.Watermark, bool, error) {
+	var watermark model.Watermark
+	err := w.DB.WithContext(ctx).Where("job_id = ?", jobID).First(&watermark).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return watermark, false, nil
+	}
+	if err != nil {
+		return watermark, false, err
+	}
+	return watermark, true, nil
+}
+
+// GetAllWatermarks Get the watermarks of all the jobs, keyed by the id of the job
+func (w *WatermarkRepositoryImpl) GetAllWatermarks(ctx context.Context) (map[uint]model.Watermark, error) {
+	var watermarks []model.Watermark
+	err := w.DB.WithContext(ctx).Find(&watermarks).Error
+	if err != nil {
+		return nil, err
+	}
+	byJob := make(map[uint]model.Watermark, len(watermarks))
+	for _, watermark := range watermarks {
+		byJob[watermark.JobID] = watermark
+	}
+	return byJob, nil
+}
+
+// SaveWatermark Insert or overwrite the watermark of a job
+func (w *WatermarkRepositoryImpl) SaveWatermark(ctx context.Context, watermark model.Watermark) error {
+	err := w.DB.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&watermark).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteWatermark Forget the watermark of a job, so its next run recomputes everything
+func (w *WatermarkRepositoryImpl) DeleteWatermark(ctx context.Context, jobID uint) error {
+	err := w.DB.WithContext(ctx).Where("job_id = ?", jobID).Delete(&model.Watermark{}).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package sqlbuilder
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// identifierPattern Tables, columns and aliases are plain identifiers. Anything else is rejected instead of escaped
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// functions The SQL functions expressions can call
+var functions = []string{"min", "max", "avg", "count", "sum", "date", "datetime", "strftime"}
+
+// comparisonOperators The operators conditions can compare with
+var comparisonOperators = []string{"=", "<>", ">", ">=", "<", "<="}
+
+// binaryOperators The arithmetic and concatenation operators expressions can use
+var binaryOperators = []string{"+", "-", "*", "/", "%", "||"}
+
+// Expr A SQL expression together with the values bound to its parameters. An expression built from an invalid
+// identifier, function or operator carries the error, which is returned when the query is built
+type Expr struct {
+	sql  string
+	args []interface{}
+	err  error
+}
+
+// Column Returns the quoted identifier of a column
+func Column(name string) Expr {
+	quoted, err := QuoteIdentifier(name)
+	return Expr{sql: quoted, err: err}
+}
+
+// Value Returns a parameter which the value is bound to
+func Value(value interface{}) Expr {
+	return Expr{sql: "?", args: []interface{}{value}}
+}
+
+// Func Returns the call of a function of the allowlist
+func Func(name string, args ...Expr) Expr {
+	name = strings.ToLower(name)
+	if !slices.Contains(functions, name) {
+		return Expr{err: fmt.Errorf("function is not allowed: %s", name)}
+	}
+	call := join(", ", args...)
+	call.sql = name + "(" + call.sql + ")"
+	return call
+}
+
+// Distinct Returns the argument of an aggregate function which only aggregates the distinct values
+func Distinct(expr Expr) Expr {
+	return Expr{sql: "DISTINCT " + expr.sql, args: expr.args, err: expr.err}
+}
+
+// Binary Returns the arithmetic or concatenation of two expressions
+func Binary(left Expr, operator string, right Expr) Expr {
+	if !slices.Contains(binaryOperators, operator) {
+		return Expr{err: fmt.Errorf("operator is not allowed: %s", operator)}
+	}
+	expr := join(" "+operator+" ", left, right)
+	expr.sql = "(" + expr.sql + ")"
+	return expr
+}
+
+// As Returns the expression under the quoted alias, for the select list
+func (e Expr) As(alias string) Expr {
+	quoted, err := QuoteIdentifier(alias)
+	if err != nil {
+		return Expr{err: err}
+	}
+	return Expr{sql: e.sql + " AS " + quoted, args: e.args, err: e.err}
+}
+
+// Compare Returns the condition which compares two expressions
+func Compare(left Expr, operator string, right Expr) Expr {
+	if !slices.Contains(comparisonOperators, operator) {
+		return Expr{err: fmt.Errorf("operator is not allowed: %s", operator)}
+	}
+	return join(" "+operator+" ", left, right)
+}
+
+// Between Returns the condition which matches the values from low to high, both included
+func Between(expr Expr, low Expr, high Expr) Expr {
+	bounds := join(" AND ", low, high)
+	return join(" BETWEEN ", expr, bounds)
+}
+
+// In Returns the condition which matches any of the values
+func In(expr Expr, values ...Expr) Expr {
+	if len(values) == 0 {
+		return Expr{err: fmt.Errorf("IN needs at least one value")}
+	}
+	list := join(", ", values...)
+	list.sql = "(" + list.sql + ")"
+	return join(" IN ", expr, list)
+}
+
+// IsNull Returns the condition which matches NULL
+func IsNull(expr Expr) Expr {
+	return Expr{sql: expr.sql + " IS NULL", args: expr.args, err: expr.err}
+}
+
+// IsNotNull Returns the condition which matches anything but NULL
+func IsNotNull(expr Expr) Expr {
+	return Expr{sql: expr.sql + " IS NOT NULL", args: expr.args, err: expr.err}
+}
+
+// QuoteIdentifier Returns the identifier in double quotes. Names which are not plain identifiers are rejected
+func QuoteIdentifier(name string) (string, error) {
+	if !identifierPattern.MatchString(name) {
+		return "", fmt.Errorf("invalid identifier: %q", name)
+	}
+	return `"` + name + `"`, nil
+}
+
+// join Joins the expressions with the separator, keeping the order of their arguments and the first error
+func join(separator string, exprs ...Expr) Expr {
+	var joined Expr
+	parts := make([]string, 0, len(exprs))
+	for _, expr := range exprs {
+		if expr.err != nil && joined.err == nil {
+			joined.err = expr.err
+		}
+		parts = append(parts, expr.sql)
+		joined.args = append(joined.args, expr.args...)
+	}
+	joined.sql = strings.Join(parts, separator)
+	return joined
+}
+
+// SelectQuery A SELECT from a single table. The conditions of Where are joined with AND
+type SelectQuery struct {
+	columns []Expr
+	table   string
+	where   []Expr
+	groupBy []Expr
+	orderBy []Expr
+}
+
+// Select Starts a query which selects the given columns
+func Select(columns ...Expr) *SelectQuery {
+	return &SelectQuery{columns: columns}
+}
+
+// Columns Adds columns to the select list
+func (q *SelectQuery) Columns(columns ...Expr) *SelectQuery {
+	q.columns = append(q.columns, columns...)
+	return q
+}
+
+func (q *SelectQuery) From(table string) *SelectQuery {
+	q.table = table
+	return q
+}
+
+func (q *SelectQuery) Where(conditions ...Expr) *SelectQuery {
+	q.where = append(q.where, conditions...)
+	return q
+}
+
+func (q *SelectQuery) GroupBy(exprs ...Expr) *SelectQuery {
+	q.groupBy = append(q.groupBy, exprs...)
+	return q
+}
+
+func (q *SelectQuery) OrderBy(exprs ...Expr) *SelectQuery {
+	q.orderBy = append(q.orderBy, exprs...)
+	return q
+}
+
+// Groups Returns the expressions the query groups by
+func (q *SelectQuery) Groups() []Expr {
+	return slices.Clone(q.groupBy)
+}
+
+// Ungrouped Returns the query which selects the given columns from every row the query aggregates, instead of from
+// every group. The rows of a group are next to each other since they are ordered by the groups
+func (q *SelectQuery) Ungrouped(columns ...Expr) *SelectQuery {
+	return &SelectQuery{columns: columns, table: q.table, where: slices.Clone(q.where), orderBy: slices.Clone(q.groupBy)}
+}
+
+// Build Returns the text of the query and the values of its parameters in order
+func (q *SelectQuery) Build() (string, []interface{}, error) {
+	if len(q.columns) == 0 {
+		return "", nil, fmt.Errorf("query selects no columns")
+	}
+	table, err := QuoteIdentifier(q.table)
+	if err != nil {
+		return "", nil, err
+	}
+	query := join(", ", q.columns...)
+	query.sql = "SELECT " + query.sql + " FROM " + table
+	if len(q.where) > 0 {
+		query = join(" WHERE ", query, join(" AND ", q.where...))
+	}
+	if len(q.groupBy) > 0 {
+		query = join(" GROUP BY ", query, join(", ", q.groupBy...))
+	}
+	if len(q.orderBy) > 0 {
+		query = join(" ORDER BY ", query, join(", ", q.orderBy...))
+	}
+	if query.err != nil {
+		return "", nil, query.err
+	}
+	return query.sql, query.args, nil
+}
@@ -0,0 +1,99 @@
+package sqlbuilder
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestBuildQuotesIdentifiersAndBindsValues(t *testing.T) {
+	column := Column("timestamp")
+	text, args, err := Select(Func("avg", Column("weight")).As("result"), column.As("day")).
+		From("registration").
+		Where(Compare(Func("date", column), "=", Value("2022-12-21")), In(Column("weight"), Value(1), Value(2))).
+		GroupBy(column).
+		OrderBy(column).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `SELECT avg("weight") AS "result", "timestamp" AS "day" FROM "registration" ` +
+		`WHERE date("timestamp") = ? AND "weight" IN (?, ?) GROUP BY "timestamp" ORDER BY "timestamp"`
+	if text != expected {
+		t.Errorf("unexpected query:\n%s\nexpected:\n%s", text, expected)
+	}
+	if !slices.Equal(args, []interface{}{"2022-12-21", 1, 2}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+func TestBuildRejectsInvalidIdentifiers(t *testing.T) {
+	identifiers := []string{
+		`timestamp) = 1; DROP TABLE registration; --`,
+		`weight"`,
+		`registration r`,
+		`1weight`,
+		``,
+	}
+	for _, identifier := range identifiers {
+		queries := map[string]*SelectQuery{
+			"table":  Select(Func("count", Column("weight"))).From(identifier),
+			"column": Select(Func("count", Column(identifier))).From("registration"),
+			"alias":  Select(Func("count", Column("weight")).As(identifier)).From("registration"),
+			"where":  Select(Func("count", Column("weight"))).From("registration").Where(IsNull(Column(identifier))),
+		}
+		for name, query := range queries {
+			if text, _, err := query.Build(); err == nil {
+				t.Errorf("%s %q was accepted: %s", name, identifier, text)
+			}
+		}
+	}
+}
+
+func TestBuildRejectsFunctionsAndOperatorsOutsideTheAllowlist(t *testing.T) {
+	queries := map[string]*SelectQuery{
+		"function":   Select(Func("load_extension", Value("evil"))).From("registration"),
+		"comparison": Select(Column("weight")).From("registration").Where(Compare(Column("weight"), "= 1 OR 1 =", Value(1))),
+		"binary":     Select(Binary(Column("weight"), "; DROP TABLE registration; --", Value(1))).From("registration"),
+		"empty in":   Select(Column("weight")).From("registration").Where(In(Column("weight"))),
+	}
+	for name, query := range queries {
+		if text, _, err := query.Build(); err == nil {
+			t.Errorf("%s was accepted: %s", name, text)
+		}
+	}
+}
+
+func TestValuesAreNeverWrittenIntoTheQuery(t *testing.T) {
+	value := "'; DROP TABLE registration; --"
+	text, args, err := Select(Column("weight")).From("registration").Where(Compare(Column("weight"), "=", Value(value))).Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if text != `SELECT "weight" FROM "registration" WHERE "weight" = ?` {
+		t.Errorf("unexpected query: %s", text)
+	}
+	if len(args) != 1 || args[0] != value {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+func TestUngroupedSelectsTheRowsOfTheGroupsInOrder(t *testing.T) {
+	day := Func("date", Column("timestamp"))
+	grouped := Select(Func("count", Column("weight")).As("result")).
+		From("registration").
+		Where(Compare(Column("weight"), ">", Value(0))).
+		GroupBy(day).
+		OrderBy(day)
+	text, args, err := grouped.Ungrouped(day.As("day"), Column("weight").As("result")).Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `SELECT date("timestamp") AS "day", "weight" AS "result" FROM "registration" WHERE "weight" > ? ` +
+		`ORDER BY date("timestamp")`
+	if text != expected {
+		t.Errorf("unexpected query:\n%s\nexpected:\n%s", text, expected)
+	}
+	if !slices.Equal(args, []interface{}{0}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
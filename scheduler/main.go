@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
 	"os/signal"
 	"scheduler/cmd"
 	"scheduler/config"
@@ -16,8 +17,15 @@ func main() {
 	// Setting up the configuration before starting the server
 	cmd.SetUpConfig()
 	cmd.SetUpLogger()
+	// "main migrate <command>" only manages the schema of the state store
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		cmd.Migrate(os.Args[2:])
+		return
+	}
 	cmd.SetUpDatabase()
+	cmd.SetUpDataSources()
 	cmd.SetUpCron()
+	cmd.SetUpClock()
 	cmd.LoadSchedules()
 
 	r := router.NewFiberRouter()
@@ -41,10 +49,9 @@ func main() {
 
 	// shutting down the server
 	fmt.Println("\nShutting down gracefully, press Ctrl+C again to force")
-	_, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	cmd.ShutDown()
+	// stopping the server first so no manual run is triggered while the in-flight runs are drained
 	if err := r.ShutdownWithTimeout(5 * time.Second); err != nil {
 		fmt.Println(err)
 	}
+	cmd.ShutDown()
 }
@@ -44,4 +44,52 @@ var (
 		ERROR_TYPE_NOT_FOUND,
 		"Job is not scheduled",
 	)
+
+	JobNotActiveError = createFixedExceptionErrors(
+		http.StatusConflict,
+		ERROR_TYPE_CONFLICT,
+		"Job is not active",
+	)
+
+	JobNotPausedError = createFixedExceptionErrors(
+		http.StatusConflict,
+		ERROR_TYPE_CONFLICT,
+		"Job is not paused",
+	)
+
+	RunNotInProgressError = createFixedExceptionErrors(
+		http.StatusConflict,
+		ERROR_TYPE_CONFLICT,
+		"Run is not in progress",
+	)
+
+	InvalidQueryError = createFixedExceptionErrors(
+		http.StatusUnprocessableEntity,
+		ERROR_TYPE_VALIDATION_ERROR,
+		"Query of the job is invalid",
+	)
+
+	QueryNotReadOnlyError = createFixedExceptionErrors(
+		http.StatusUnprocessableEntity,
+		ERROR_TYPE_VALIDATION_ERROR,
+		"Query of the job is not read-only",
+	)
+
+	BackfillNotSupportedError = createFixedExceptionErrors(
+		http.StatusUnprocessableEntity,
+		ERROR_TYPE_VALIDATION_ERROR,
+		"Duration of the job can not be backfilled",
+	)
+
+	BackfillTooLargeError = createFixedExceptionErrors(
+		http.StatusUnprocessableEntity,
+		ERROR_TYPE_VALIDATION_ERROR,
+		"Backfill covers too many periods",
+	)
+
+	BackfillNotInProgressError = createFixedExceptionErrors(
+		http.StatusConflict,
+		ERROR_TYPE_CONFLICT,
+		"Backfill is not in progress",
+	)
 )
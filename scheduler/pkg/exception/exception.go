@@ -1,6 +1,9 @@
 package exception
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"net/http"
+)
 
 // ExceptionErrors is used as our project error response.
 // All error response will be in this format.
@@ -25,6 +28,8 @@ func (cErrs *ExceptionErrors) MarshalJSON() ([]byte, error) {
 type ExceptionError struct {
 	Message string    `json:"message"`
 	Type    errorType `json:"type"`
+	// Field names the field of the request which failed the validation
+	Field string `json:"field,omitempty"`
 }
 
 func (cErr *ExceptionError) Error() string {
@@ -39,6 +44,21 @@ func NewExceptionErrors(httpStatusCode int, globalMessage string) *ExceptionErro
 	}
 }
 
+// NewValidationError allocates the ExceptionErrors of a field of a request which failed the validation
+func NewValidationError(field string, message string) *ExceptionErrors {
+	return &ExceptionErrors{
+		GlobalMessage:  field + ": " + message,
+		HttpStatusCode: http.StatusUnprocessableEntity,
+		ErrItems: []*ExceptionError{
+			{
+				Message: message,
+				Type:    ERROR_TYPE_VALIDATION_ERROR,
+				Field:   field,
+			},
+		},
+	}
+}
+
 func createFixedExceptionErrors(httpStatusCode int, t errorType, m string) *ExceptionErrors {
 	return &ExceptionErrors{
 		GlobalMessage:  m,
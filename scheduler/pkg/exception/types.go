@@ -8,4 +8,5 @@ const (
 	ERROR_TYPE_VALIDATION_ERROR errorType = "ValidationError"
 	ERROR_TYPE_UPDATE_FAILED    errorType = "UpdateFailed"
 	ERROR_TYPE_CREATE_FAILED    errorType = "CreateFailed"
+	ERROR_TYPE_CONFLICT         errorType = "Conflict"
 )
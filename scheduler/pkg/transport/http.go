@@ -31,6 +31,12 @@ type HttpRequest struct {
 	Params     map[string]string
 }
 
+// HttpResponse holds the status and the raw body returned by the remote server
+type HttpResponse struct {
+	StatusCode int
+	Body       []byte
+}
+
 func NewHTTPClient() *http.Client {
 	transport := &http.Transport{
 		DialContext: (&net.Dialer{
@@ -99,23 +105,24 @@ func MakeHTTPRequest(ctx context.Context, req HttpRequest) (*http.Response, erro
 	}
 }
 
-func RequestAndParseJSONBody(ctx context.Context, req HttpRequest) error {
+func RequestAndParseJSONBody(ctx context.Context, req HttpRequest) (*HttpResponse, error) {
 	resp, err := MakeHTTPRequest(ctx, req)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return err
+		return &HttpResponse{StatusCode: resp.StatusCode}, err
 	}
-	defer resp.Body.Close()
 
 	logger.Log.Debug(fmt.Sprintf("response body from %s is %s", req.Url, body))
 
+	httpResponse := &HttpResponse{StatusCode: resp.StatusCode, Body: body}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return errors.New("unexpected status: " + resp.Status)
+		return httpResponse, errors.New("unexpected status: " + resp.Status)
 	}
 
-	return err
+	return httpResponse, nil
 }